@@ -5,8 +5,10 @@ import (
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
 	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
 	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
 	"github.com/soumya-codes/airline-reservation-poc/internal/store"
 )
@@ -15,6 +17,9 @@ const (
 	defaultMaxConn     = 10
 	defaultTimeout     = 6 * time.Second
 	defaultTxIsolation = pgtx.ReadCommitted
+	defaultPoolPolicy  = pgpool.Unordered
+	defaultBookingMode = ClientSideBooking
+	defaultExecMode    = pgx.QueryExecModeCacheStatement
 )
 
 type Config struct {
@@ -25,22 +30,72 @@ type Config struct {
 	TxIsolation    pgtx.IsolationLevel
 	// TODO: Implement a retry mechanism using the MaxRetries field
 	MaxRetries int
+	// PoolPolicy decides which waiting booking is handed a connection when one is released.
+	PoolPolicy pgpool.AcquisitionPolicy
+	// PriorityClass assigns the pool priority of a booking attempt under pgpool.PriorityFIFO, nil puts every attempt in one class.
+	PriorityClass func(passengerID int32, attempt int) pgpool.Priority
+	// SavepointRetries is how often a failed seat claim is retried from a savepoint in its transaction, 0 doesn't use savepoints.
+	SavepointRetries int
+	// BookingMode decides whether a seat is claimed by the client, pipelined or by the server.
+	BookingMode BookingMode
+	// ActivitySampleInterval is how often pg_stat_activity is sampled for the backends of the run, 0 disables sampling.
+	ActivitySampleInterval time.Duration
+	// LockGraphInterval is how often pg_locks is sampled for the lock wait-for graph, 0 disables it.
+	LockGraphInterval time.Duration
+	// LockGraphDir is the directory the lock wait-for graph is written to.
+	LockGraphDir string
+	// StatementStats reports the per query statistics of pg_stat_statements, which it resets for the whole server.
+	StatementStats bool
+	// ExplainDir is the directory the plans of the lock strategy's queries are written to, empty doesn't explain them.
+	ExplainDir string
+	// QueryTraceDir is the directory the statement trace is written to, empty doesn't trace the statements.
+	QueryTraceDir string
+	// MetricsAddr is the address the Prometheus metrics are served on, empty doesn't serve them.
+	MetricsAddr string
+	// SpanDir is the directory the spans are written to, empty doesn't record them.
+	SpanDir string
+	// HeatmapDir is the directory the seat contention heatmap is written to, empty only prints it.
+	HeatmapDir string
+	// CabinLayout is the cabin layout of the trip to book, empty books the next available trip of any layout.
+	CabinLayout string
+	// SeatMapDir is the directory the final seat map is written to, empty only prints it.
+	SeatMapDir string
+	// ReportDir is the directory the run report is written to, empty doesn't write it.
+	ReportDir string
+	// ExperimentHistory records the run and its attempts in the experiment history tables.
+	ExperimentHistory bool
 }
 
+// BookingMode decides how the seat lookup and the seat assignment of a booking are sent to the server.
+type BookingMode string
+
+const (
+	// ClientSideBooking looks up the seat with the lock strategy and assigns it in a separate statement.
+	ClientSideBooking BookingMode = "CLIENT"
+	// PipelinedBooking looks up and assigns the seat in a single statement, and sends it together
+	// with BEGIN and COMMIT to the server as a single batch.
+	PipelinedBooking BookingMode = "PIPELINED"
+	// ServerSideBooking calls the book_seat function, which looks up, locks and assigns the seat inside Postgres.
+	ServerSideBooking BookingMode = "SERVER"
+)
+
 func DefaultConfig() *Config {
 	return &Config{
 		PostgresConfig: &pgconn.Config{
-			Host:     "localhost",
-			Port:     5432,
-			Username: "postgres",
-			Password: "postgres",
-			Database: "airline_reservation_db",
+			Host:          "localhost",
+			Port:          5432,
+			Username:      "postgres",
+			Password:      "postgres",
+			Database:      "airline_reservation_db",
+			QueryExecMode: defaultExecMode,
 		},
 		MaxConn:      defaultMaxConn,
 		Timeout:      defaultTimeout,
 		LockStrategy: seat.GetSeatWithExclusiveLock,
 		TxIsolation:  defaultTxIsolation,
 		MaxRetries:   1,
+		PoolPolicy:   defaultPoolPolicy,
+		BookingMode:  defaultBookingMode,
 	}
 }
 
@@ -87,6 +142,163 @@ func WithTxIsolation(isolation pgtx.IsolationLevel) Option {
 	}
 }
 
+// WithSavepointRetries rolls a failed seat claim back to a savepoint and retries it up to savepointRetries times within
+// the same transaction, before the transaction itself is retried. Under READ COMMITTED a retried claim sees the seats
+// booked in the meantime and moves on to the next seat, under REPEATABLE READ and SERIALIZABLE it still sees the snapshot
+// the transaction started with.
+func WithSavepointRetries(savepointRetries int) Option {
+	if savepointRetries < 0 {
+		log.Fatal("savepointRetries must not be negative")
+	}
+
+	return func(c *Config) {
+		c.SavepointRetries = savepointRetries
+	}
+}
+
+// WithBookingMode books the seats in the mode. Lock strategies without a pipelined or server-side counterpart are always
+// claimed by the client, and savepoint retries only apply to ClientSideBooking.
+func WithBookingMode(mode BookingMode) Option {
+	return func(c *Config) {
+		c.BookingMode = mode
+	}
+}
+
+func WithPoolPolicy(policy pgpool.AcquisitionPolicy) Option {
+	return func(c *Config) {
+		c.PoolPolicy = policy
+	}
+}
+
+func WithPriorityClass(priorityClass func(passengerID int32, attempt int) pgpool.Priority) Option {
+	return func(c *Config) {
+		c.PriorityClass = priorityClass
+	}
+}
+
+// WithSessionSettings sets the session level settings, e.g. lock_timeout, applied to every database connection.
+func WithSessionSettings(settings pgconn.SessionSettings) Option {
+	return func(c *Config) {
+		c.PostgresConfig.SessionSettings = settings
+	}
+}
+
+// WithQueryExecMode sets the protocol pgx uses to execute the queries, e.g. pgx.QueryExecModeSimpleProtocol.
+func WithQueryExecMode(mode pgx.QueryExecMode) Option {
+	return func(c *Config) {
+		c.PostgresConfig.QueryExecMode = mode
+	}
+}
+
+func WithStatementCacheCapacity(capacity int) Option {
+	if capacity <= 0 {
+		log.Fatal("statement cache capacity must be greater than 0")
+	}
+
+	return func(c *Config) {
+		c.PostgresConfig.StatementCacheCapacity = capacity
+	}
+}
+
+// WithActivitySampleInterval samples pg_stat_activity every interval while the seats are booked.
+func WithActivitySampleInterval(interval time.Duration) Option {
+	if interval <= 0 {
+		log.Fatal("activity sample interval must be greater than 0")
+	}
+
+	return func(c *Config) {
+		c.ActivitySampleInterval = interval
+	}
+}
+
+// WithLockGraph samples the lock waits every interval while the seats are booked,
+// and writes the wait-for graph of the run to dir.
+func WithLockGraph(interval time.Duration, dir string) Option {
+	if interval <= 0 {
+		log.Fatal("lock graph interval must be greater than 0")
+	}
+
+	return func(c *Config) {
+		c.LockGraphInterval = interval
+		c.LockGraphDir = dir
+	}
+}
+
+// WithStatementStats reports the execution statistics of every query of the run from pg_stat_statements.
+func WithStatementStats() Option {
+	return func(c *Config) {
+		c.StatementStats = true
+	}
+}
+
+// WithExplainPlans explains the queries of the lock strategy with EXPLAIN (ANALYZE, BUFFERS) against the trip about to be booked,
+// and writes their plans to dir.
+func WithExplainPlans(dir string) Option {
+	return func(c *Config) {
+		c.ExplainDir = dir
+	}
+}
+
+// WithQueryTrace traces every statement sent by the bookings, and writes the trace to dir.
+func WithQueryTrace(dir string) Option {
+	return func(c *Config) {
+		c.QueryTraceDir = dir
+	}
+}
+
+// WithMetrics serves the booking metrics in the Prometheus text format on addr, e.g. ":2112", at /metrics.
+func WithMetrics(addr string) Option {
+	if addr == "" {
+		log.Fatal("metrics address must not be empty")
+	}
+
+	return func(c *Config) {
+		c.MetricsAddr = addr
+	}
+}
+
+// WithSpans records OpenTelemetry style spans of the booking flows and their queries, and writes them to dir as OTLP JSON.
+func WithSpans(dir string) Option {
+	return func(c *Config) {
+		c.SpanDir = dir
+	}
+}
+
+// WithSeatHeatmap writes the heatmap of the seats returned by the lock strategy to dir as an SVG.
+func WithSeatHeatmap(dir string) Option {
+	return func(c *Config) {
+		c.HeatmapDir = dir
+	}
+}
+
+// WithCabinLayout books the next available trip of the cabin layout with the name, see the cabin_layout table.
+func WithCabinLayout(name string) Option {
+	return func(c *Config) {
+		c.CabinLayout = name
+	}
+}
+
+// WithSeatMap writes the final seat map of the trip to dir as plain text and JSON.
+func WithSeatMap(dir string) Option {
+	return func(c *Config) {
+		c.SeatMapDir = dir
+	}
+}
+
+// WithReport writes the report of the run to dir as JSON, CSV and Markdown.
+func WithReport(dir string) Option {
+	return func(c *Config) {
+		c.ReportDir = dir
+	}
+}
+
+// WithExperimentHistory records the run and its attempts in the experiment_run and experiment_attempt tables.
+func WithExperimentHistory() Option {
+	return func(c *Config) {
+		c.ExperimentHistory = true
+	}
+}
+
 func NewConfig(opts ...Option) *Config {
 	cfg := DefaultConfig()
 	for _, opt := range opts {
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestDefaultQueryExecMode(t *testing.T) {
+	if got := NewConfig().PostgresConfig.QueryExecMode; got != pgx.QueryExecModeCacheStatement {
+		t.Errorf("default QueryExecMode = %s, want %s", got, pgx.QueryExecModeCacheStatement)
+	}
+}
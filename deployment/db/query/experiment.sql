@@ -0,0 +1,42 @@
+-- name: GetExperimentRun :one
+SELECT id, run_id, git_sha, trip_id, started_at, elapsed_us, isolation_level, lock_strategy, claim_mode, pool_size, pool_policy,
+       max_retries, savepoint_retries, query_exec_mode, cabin_layout, session_settings, server_settings, server_settings_hash,
+       passengers, bookings, attempts, failed_attempts, throughput, booking_p50_us, booking_p90_us, booking_p99_us, booking_max_us
+FROM experiment_run WHERE run_id = $1;
+
+-- name: GetExperimentRunsByConfiguration :many
+-- The empty filters match the runs of any claim mode, pool policy, query exec mode, cabin layout or session settings,
+-- and max_retries matches any number of retries when it is 0.
+SELECT id, run_id, git_sha, trip_id, started_at, elapsed_us, isolation_level, lock_strategy, claim_mode, pool_size, pool_policy,
+       max_retries, savepoint_retries, query_exec_mode, cabin_layout, session_settings, server_settings, server_settings_hash,
+       passengers, bookings, attempts, failed_attempts, throughput, booking_p50_us, booking_p90_us, booking_p99_us, booking_max_us
+FROM experiment_run
+WHERE isolation_level = sqlc.arg(isolation_level) AND lock_strategy = sqlc.arg(lock_strategy) AND pool_size = sqlc.arg(pool_size)
+  AND (sqlc.arg(claim_mode)::text = '' OR claim_mode = sqlc.arg(claim_mode)::text)
+  AND (sqlc.arg(pool_policy)::text = '' OR pool_policy = sqlc.arg(pool_policy)::text)
+  AND (sqlc.arg(max_retries)::int = 0 OR max_retries = sqlc.arg(max_retries)::int)
+  AND (sqlc.arg(query_exec_mode)::text = '' OR query_exec_mode = sqlc.arg(query_exec_mode)::text)
+  AND (sqlc.arg(cabin_layout)::text = '' OR cabin_layout = sqlc.arg(cabin_layout)::text)
+  AND (sqlc.arg(session_settings)::text = '' OR session_settings = sqlc.arg(session_settings)::text)
+  AND server_settings_hash LIKE sqlc.arg(server_settings_hash_pattern)::text
+  AND git_sha LIKE sqlc.arg(git_sha_pattern)::text
+ORDER BY started_at DESC LIMIT sqlc.arg(max_runs);
+
+-- name: GetServerSettings :one
+-- The server settings of the names, and the hash of every setting that wasn't set by the session or the client.
+SELECT md5(string_agg(name || '=' || setting, ',' ORDER BY name))::text                                    AS settings_hash,
+       coalesce(jsonb_object_agg(name, setting) FILTER (WHERE name = ANY (sqlc.arg(names)::text[])), '{}')::jsonb AS settings
+FROM pg_settings
+WHERE source NOT IN ('session', 'client');
+
+-- name: InsertExperimentAttempts :copyfrom
+INSERT INTO experiment_attempt (run_id, passenger_id, attempt, succeeded, seat_id, sqlstate, phase, acquire_wait_us, attempt_us,
+                                lock_hold_us, round_trips)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: InsertExperimentRun :exec
+INSERT INTO experiment_run (run_id, git_sha, trip_id, started_at, elapsed_us, isolation_level, lock_strategy, claim_mode, pool_size,
+                            pool_policy, max_retries, savepoint_retries, query_exec_mode, cabin_layout, session_settings,
+                            server_settings, server_settings_hash, passengers, bookings, attempts, failed_attempts, throughput,
+                            booking_p50_us, booking_p90_us, booking_p99_us, booking_max_us)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26);
@@ -0,0 +1,56 @@
+-- name: GetBackendActivity :many
+SELECT COALESCE(pid, 0)::int AS pid,
+       COALESCE(application_name, '')::text AS application_name,
+       COALESCE(state, '')::text AS state,
+       COALESCE(wait_event_type, '')::text AS wait_event_type,
+       COALESCE(wait_event, '')::text AS wait_event,
+       COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - xact_start) * 1000, 0)::float8 AS xact_age_ms,
+       COALESCE(query, '')::text AS query
+FROM pg_stat_activity
+WHERE application_name LIKE sqlc.arg(application_name_pattern)::text AND pid <> pg_backend_pid()
+ORDER BY pid;
+
+-- name: GetLockWaits :many
+SELECT w.pid::int AS waiter_pid,
+       COALESCE(w.application_name, '')::text AS waiter_application_name,
+       b.pid::int AS holder_pid,
+       COALESCE(b.application_name, '')::text AS holder_application_name,
+       COALESCE(l.locktype, '')::text AS lock_type,
+       COALESCE(l.mode, '')::text AS lock_mode,
+       COALESCE(l.relation::regclass::text, '')::text AS relation
+FROM pg_stat_activity w
+         JOIN pg_locks l ON l.pid = w.pid AND NOT l.granted
+         CROSS JOIN LATERAL unnest(pg_blocking_pids(w.pid)) AS blocking(pid)
+         JOIN pg_stat_activity b ON b.pid = blocking.pid
+WHERE w.application_name LIKE sqlc.arg(application_name_pattern)::text
+ORDER BY w.pid, b.pid;
+
+-- name: GetDatabaseStats :one
+SELECT COALESCE(deadlocks, 0)::bigint AS deadlocks,
+       COALESCE(conflicts, 0)::bigint AS conflicts,
+       COALESCE(xact_commit, 0)::bigint AS xact_commit,
+       COALESCE(xact_rollback, 0)::bigint AS xact_rollback,
+       COALESCE(tup_updated, 0)::bigint AS tup_updated,
+       pg_wal_lsn_diff(pg_current_wal_lsn(), '0/0')::bigint AS wal_lsn
+FROM pg_stat_database
+WHERE datname = current_database();
+
+-- name: GetTableStats :one
+SELECT COALESCE(n_tup_upd, 0)::bigint AS n_tup_upd,
+       COALESCE(n_tup_hot_upd, 0)::bigint AS n_tup_hot_upd,
+       COALESCE(n_dead_tup, 0)::bigint AS n_dead_tup
+FROM pg_stat_user_tables
+WHERE relname = sqlc.arg(table_name)::text;
+
+-- name: ResetStatementStats :exec
+SELECT pg_stat_statements_reset();
+
+-- name: GetStatementStats :many
+SELECT COALESCE(query, '')::text AS query,
+       COALESCE(calls, 0)::bigint AS calls,
+       COALESCE(total_exec_time, 0)::float8 AS total_exec_time,
+       COALESCE(max_exec_time, 0)::float8 AS max_exec_time,
+       COALESCE(rows, 0)::bigint AS rows
+FROM pg_stat_statements
+WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+ORDER BY total_exec_time DESC;
@@ -16,5 +16,25 @@ SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL
 -- name: BookSeat :one
 UPDATE reservation SET passenger_id = $1 WHERE id = $2 RETURNING 1;
 
+-- name: ClaimSeatWithNoLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1) RETURNING id, seat_id;
+
+-- name: ClaimSeatWithSharedLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE) RETURNING id, seat_id;
+
+-- name: ClaimSeatWithSharedLockSkipped :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE SKIP LOCKED) RETURNING id, seat_id;
+
+-- name: ClaimSeatWithExclusiveLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE) RETURNING id, seat_id;
+
+-- name: ClaimSeatWithExclusiveLockSkipped :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING id, seat_id;
+
+-- name: BookSeatWithFunction :one
+SELECT id, seat_id FROM book_seat(sqlc.arg(trip_id)::int, sqlc.arg(passenger_id)::int, sqlc.arg(lock_mode)::text);
+
 -- name: GetTripSeats :many
-SELECT seat_id, passenger_id FROM reservation WHERE trip_id = $1 ORDER BY seat_id;
\ No newline at end of file
+SELECT reservation.seat_id, COALESCE(reservation.passenger_id, 0)::int AS passenger_id, COALESCE(passenger.name, '')::text AS passenger_name
+FROM reservation LEFT JOIN passenger ON passenger.id = reservation.passenger_id
+WHERE reservation.trip_id = $1 ORDER BY reservation.id;
\ No newline at end of file
@@ -2,4 +2,12 @@
 UPDATE trip SET booked = TRUE WHERE id = $1 RETURNING 1;
 
 -- name: GetNextAvailableTrip :one
-SELECT id FROM trip WHERE booked = FALSE ORDER BY schedule LIMIT 1 FOR UPDATE SKIP LOCKED;
\ No newline at end of file
+SELECT id FROM trip WHERE booked = FALSE ORDER BY schedule LIMIT 1 FOR UPDATE SKIP LOCKED;
+
+-- name: GetNextAvailableTripWithCabinLayout :one
+SELECT trip.id FROM trip JOIN cabin_layout ON cabin_layout.id = trip.cabin_layout_id
+WHERE trip.booked = FALSE AND cabin_layout.name = $1 ORDER BY trip.schedule LIMIT 1 FOR UPDATE OF trip SKIP LOCKED;
+
+-- name: GetTripCabinLayout :one
+SELECT cabin_layout.id, cabin_layout.name, cabin_layout.row_count, cabin_layout.seat_letters, cabin_layout.aisle_after, cabin_layout.blocked_seats
+FROM trip JOIN cabin_layout ON cabin_layout.id = trip.cabin_layout_id WHERE trip.id = $1;
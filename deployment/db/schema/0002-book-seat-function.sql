@@ -0,0 +1,31 @@
+-- Function to look up, lock and assign the next available seat of a trip in a single call.
+-- p_lock_mode is the row lock taken on the seat while looking it up:
+-- NONE, SHARE, SHARE SKIP LOCKED, UPDATE or UPDATE SKIP LOCKED.
+-- Returns the booked seat, or no rows if no seat is available.
+CREATE OR REPLACE FUNCTION book_seat(p_trip_id INT, p_passenger_id INT, p_lock_mode TEXT) RETURNS SETOF reservation AS
+$$
+DECLARE
+    v_id INT;
+BEGIN
+    CASE p_lock_mode
+        WHEN 'NONE' THEN
+            SELECT r.id INTO v_id FROM reservation r WHERE r.trip_id = p_trip_id AND r.passenger_id IS NULL ORDER BY r.id LIMIT 1;
+        WHEN 'SHARE' THEN
+            SELECT r.id INTO v_id FROM reservation r WHERE r.trip_id = p_trip_id AND r.passenger_id IS NULL ORDER BY r.id LIMIT 1 FOR SHARE;
+        WHEN 'SHARE SKIP LOCKED' THEN
+            SELECT r.id INTO v_id FROM reservation r WHERE r.trip_id = p_trip_id AND r.passenger_id IS NULL ORDER BY r.id LIMIT 1 FOR SHARE SKIP LOCKED;
+        WHEN 'UPDATE' THEN
+            SELECT r.id INTO v_id FROM reservation r WHERE r.trip_id = p_trip_id AND r.passenger_id IS NULL ORDER BY r.id LIMIT 1 FOR UPDATE;
+        WHEN 'UPDATE SKIP LOCKED' THEN
+            SELECT r.id INTO v_id FROM reservation r WHERE r.trip_id = p_trip_id AND r.passenger_id IS NULL ORDER BY r.id LIMIT 1 FOR UPDATE SKIP LOCKED;
+        ELSE
+            RAISE EXCEPTION 'unknown lock mode: %', p_lock_mode USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    RETURN QUERY UPDATE reservation r SET passenger_id = p_passenger_id WHERE r.id = v_id RETURNING r.*;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+-- Per query timings of the bookings, pg_stat_statements must also be listed in shared_preload_libraries
+CREATE EXTENSION IF NOT EXISTS pg_stat_statements;
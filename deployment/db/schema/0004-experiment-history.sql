@@ -0,0 +1,52 @@
+-- The history of the booking runs recorded with config.WithExperimentHistory, compared with `go run . compare`.
+-- Durations are in microseconds.
+CREATE TABLE IF NOT EXISTS experiment_run
+(
+    id                BIGSERIAL PRIMARY KEY,
+    run_id            TEXT             NOT NULL UNIQUE,
+    -- git_sha is the commit the run was built from, suffixed with -dirty if the tree had local changes, empty if unknown
+    git_sha           TEXT             NOT NULL DEFAULT '',
+    trip_id           INT              NOT NULL,
+    started_at        TIMESTAMP        NOT NULL,
+    elapsed_us        BIGINT           NOT NULL,
+    isolation_level   TEXT             NOT NULL,
+    lock_strategy     TEXT             NOT NULL,
+    claim_mode        TEXT             NOT NULL,
+    pool_size         INT              NOT NULL,
+    pool_policy       TEXT             NOT NULL,
+    max_retries       INT              NOT NULL,
+    savepoint_retries INT              NOT NULL,
+    query_exec_mode   TEXT             NOT NULL,
+    cabin_layout      TEXT             NOT NULL,
+    passengers        INT              NOT NULL,
+    bookings          INT              NOT NULL,
+    attempts          INT              NOT NULL,
+    failed_attempts   INT              NOT NULL,
+    -- throughput is the number of bookings per second
+    throughput        DOUBLE PRECISION NOT NULL,
+    booking_p50_us    BIGINT           NOT NULL,
+    booking_p90_us    BIGINT           NOT NULL,
+    booking_p99_us    BIGINT           NOT NULL,
+    booking_max_us    BIGINT           NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_run_configuration
+    ON experiment_run (isolation_level, lock_strategy, pool_size, started_at);
+
+-- Every booking attempt of a recorded run, the failed attempts carry the SQLSTATE and the phase they failed with
+CREATE TABLE IF NOT EXISTS experiment_attempt
+(
+    id              BIGSERIAL PRIMARY KEY,
+    run_id          TEXT    NOT NULL REFERENCES experiment_run (run_id) ON DELETE CASCADE,
+    passenger_id    INT     NOT NULL,
+    attempt         INT     NOT NULL,
+    succeeded       BOOLEAN NOT NULL,
+    seat_id         TEXT    NOT NULL DEFAULT '',
+    sqlstate        TEXT    NOT NULL DEFAULT '',
+    phase           TEXT    NOT NULL DEFAULT '',
+    acquire_wait_us BIGINT  NOT NULL,
+    attempt_us      BIGINT  NOT NULL,
+    lock_hold_us    BIGINT  NOT NULL,
+    round_trips     BIGINT  NOT NULL,
+    UNIQUE (run_id, passenger_id, attempt)
+);
@@ -0,0 +1,69 @@
+-- The seating of an aircraft: its rows, the seat letters of a row from the left window to the right window,
+-- the letters an aisle follows, and the seats that aren't sold, e.g. the ones in front of a galley
+CREATE TABLE cabin_layout
+(
+    id            SERIAL PRIMARY KEY,
+    name          VARCHAR(64) UNIQUE NOT NULL,
+    row_count     INT         NOT NULL CHECK (row_count > 0),
+    seat_letters  VARCHAR(16) NOT NULL CHECK (seat_letters ~ '^[A-Z]+$'),
+    aisle_after   VARCHAR(16) NOT NULL DEFAULT '',
+    blocked_seats TEXT[]      NOT NULL DEFAULT '{}'
+);
+
+-- Insert Cabin Layouts, the narrow-body layout is the 30 rows of seats A to F the trips were seated with so far,
+-- it seats exactly one passenger of the passenger table per seat
+INSERT INTO cabin_layout (name, row_count, seat_letters, aisle_after, blocked_seats)
+VALUES ('narrow-body', 30, 'ABCDEF', 'C', '{}'),
+       ('wide-body', 40, 'ABCDEFGHJK', 'CG', '{1D,1E,1F,1G}'),
+       ('regional', 18, 'ABCD', 'B', '{1C,1D}');
+
+-- The existing trips are flown by the narrow-body layout, the trips inserted from now on must name their layout
+ALTER TABLE trip
+    ADD COLUMN cabin_layout_id INT,
+    ADD CONSTRAINT fk_cabin_layout FOREIGN KEY (cabin_layout_id) REFERENCES cabin_layout (id);
+
+UPDATE trip
+SET cabin_layout_id = (SELECT id FROM cabin_layout WHERE name = 'narrow-body')
+WHERE cabin_layout_id IS NULL;
+
+ALTER TABLE trip
+    ALTER COLUMN cabin_layout_id SET NOT NULL;
+
+-- Trigger function definition to add the seats of the trip's cabin layout, row by row from the left window to the right window
+CREATE OR REPLACE FUNCTION add_seats() RETURNS TRIGGER AS
+$$
+DECLARE
+    layout cabin_layout%ROWTYPE;
+BEGIN
+    SELECT * INTO STRICT layout FROM cabin_layout WHERE id = NEW.cabin_layout_id;
+
+    INSERT INTO reservation (seat_id, trip_id)
+    SELECT seat.seat_id, NEW.id
+    FROM generate_series(1, layout.row_count) AS r(seat_row)
+             CROSS JOIN regexp_split_to_table(layout.seat_letters, '') WITH ORDINALITY AS l(letter, ordinal)
+             CROSS JOIN LATERAL (SELECT r.seat_row::TEXT || l.letter AS seat_id) AS seat
+    WHERE seat.seat_id <> ALL (layout.blocked_seats)
+    ORDER BY r.seat_row, l.ordinal;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Insert Trip Entries of the other cabin layouts, scheduled after the narrow-body trips so they are only booked when asked for
+INSERT INTO trip (airline_id, schedule, cabin_layout_id)
+SELECT t.airline_id, t.schedule::TIMESTAMP, cabin_layout.id
+FROM (VALUES
+          -- Airline 1 (Air India), wide-body
+          (1, '2024-08-01 09:00:00', 'wide-body'),
+          (1, '2024-08-02 09:00:00', 'wide-body'),
+          (1, '2024-08-03 09:00:00', 'wide-body'),
+          (1, '2024-08-04 09:00:00', 'wide-body'),
+          (1, '2024-08-05 09:00:00', 'wide-body'),
+          -- Airline 3 (SpiceJet), regional
+          (3, '2024-08-01 15:00:00', 'regional'),
+          (3, '2024-08-02 15:00:00', 'regional'),
+          (3, '2024-08-03 15:00:00', 'regional'),
+          (3, '2024-08-04 15:00:00', 'regional'),
+          (3, '2024-08-05 15:00:00', 'regional')) AS t(airline_id, schedule, cabin_layout)
+         JOIN cabin_layout ON cabin_layout.name = t.cabin_layout
+ORDER BY t.cabin_layout DESC, t.schedule;
@@ -0,0 +1,8 @@
+-- The settings a recorded run was executed with, beyond the columns of 0004-experiment-history.sql:
+-- session_settings are the session level settings applied to every connection of the run, e.g. "lock_timeout=1s ...",
+-- server_settings are the server settings that bear on the bookings, by name, and server_settings_hash is the md5 of
+-- every setting of pg_settings that wasn't set by the session or the client, so any change to postgresql.conf shows.
+ALTER TABLE experiment_run
+    ADD COLUMN IF NOT EXISTS session_settings     TEXT  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS server_settings      JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS server_settings_hash TEXT  NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- Insert Trip Entries for the test matrix, every run books a trip of its own and the matrix has more runs than the trips of
+-- 0001-initial-schema.sql. They are flown by the narrow-body layout and scheduled before the trips of the other cabin layouts,
+-- so they are booked before them
+INSERT INTO trip (airline_id, schedule, cabin_layout_id)
+SELECT airline.id, day + INTERVAL '18 hours', cabin_layout.id
+FROM generate_series(TIMESTAMP '2024-07-18', TIMESTAMP '2024-07-31', INTERVAL '1 day') AS day
+         CROSS JOIN airline
+         JOIN cabin_layout ON cabin_layout.name = 'narrow-body'
+ORDER BY day, airline.id;
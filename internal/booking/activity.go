@@ -0,0 +1,150 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
+)
+
+// slowestTraced is the number of the slowest successful bookings traced to their backends.
+const slowestTraced = 5
+
+// newRunID returns a short random ID to tell the connections of a run apart from the ones of other runs.
+func newRunID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// startActivitySampler starts sampling pg_stat_activity for the backends of the run on a connection of its own.
+// The returned stop func stops sampling and returns the samples taken, it returns no samples if sampling is disabled.
+func startActivitySampler(ctx context.Context, c *config.Config, runID string) (stop func() []pgactivity.Sample, err error) {
+	if c.ActivitySampleInterval <= 0 {
+		return func() []pgactivity.Sample { return nil }, nil
+	}
+
+	conn, err := pgconn.NewConnection(c.PostgresConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database to sample backend activity: %w", err)
+	}
+
+	sampler := pgactivity.NewSampler(conn, runID, c.ActivitySampleInterval)
+	sampler.Start(ctx)
+
+	return func() []pgactivity.Sample {
+		samples := sampler.Stop()
+		if err := pgconn.Close(conn); err != nil {
+			logrus.WithError(err).Error("error closing backend activity connection")
+		}
+
+		return samples
+	}, nil
+}
+
+// tagBooking tags the connection with the passenger and attempt while backend activity or lock waits are sampled.
+// A failure to tag only makes the booking harder to trace, so it doesn't fail the attempt.
+func tagBooking(ctx context.Context, c *config.Config, conn *pgx.Conn, passengerID int32, attempt int) {
+	if c.ActivitySampleInterval <= 0 && c.LockGraphInterval <= 0 {
+		return
+	}
+
+	if err := pgactivity.TagBooking(ctx, conn, passengerID, attempt); err != nil {
+		logrus.WithError(err).Warn("error tagging connection with the booking")
+	}
+}
+
+// tracedBooking is the last sampled state of the backend that worked on a booking.
+type tracedBooking struct {
+	status  bookingStatus
+	backend pgactivity.Backend
+	seen    bool
+}
+
+// traceBookings matches the bookings that failed and the slowest successful bookings to the backends they were last seen on.
+// outcomes holds the last attempt of every passenger.
+func traceBookings(samples []pgactivity.Sample, outcomes map[int32]bookingStatus) []tracedBooking {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	failed := make([]bookingStatus, 0)
+	succeeded := make([]bookingStatus, 0, len(outcomes))
+	for _, status := range outcomes {
+		if status.err != nil {
+			failed = append(failed, status)
+		} else {
+			succeeded = append(succeeded, status)
+		}
+	}
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].passengerID < failed[j].passengerID })
+	sort.Slice(succeeded, func(i, j int) bool { return succeeded[i].latency > succeeded[j].latency })
+
+	lastSeen := pgactivity.LastSeen(samples)
+	traced := make([]tracedBooking, 0, len(failed)+slowestTraced)
+	for _, status := range append(failed, succeeded[:min(slowestTraced, len(succeeded))]...) {
+		backend, seen := lastSeen[status.passengerID]
+		traced = append(traced, tracedBooking{status: status, backend: backend, seen: seen})
+	}
+
+	return traced
+}
+
+func printTracedBookings(traced []tracedBooking) {
+	if len(traced) == 0 {
+		return
+	}
+
+	logrus.Info("Backends of the failed and slowest bookings, as last seen in pg_stat_activity:")
+	for _, t := range traced {
+		outcome := fmt.Sprintf("booked in %v", t.status.latency)
+		if t.status.err != nil {
+			outcome = "failed"
+		}
+
+		if !t.seen {
+			logrus.Infof("Passenger: %s (%s), backend not sampled", t.status.passengerName, outcome)
+			continue
+		}
+
+		logrus.Infof("Passenger: %s (%s), attempt: %d, backend pid: %d, state: %s, wait event: %s, transaction age: %v",
+			t.status.passengerName,
+			outcome,
+			t.backend.Tag.Attempt,
+			t.backend.PID,
+			t.backend.State,
+			t.backend.WaitEventName(),
+			t.backend.XactAge,
+		)
+	}
+}
+
+// printWaitEvents prints the share of the samples spent in every wait event by the backends of the run.
+func printWaitEvents(histogram []pgactivity.WaitEventCount) {
+	if len(histogram) == 0 {
+		return
+	}
+
+	logrus.Info("Wait events of the backends, as sampled from pg_stat_activity:")
+	for _, count := range histogram {
+		logrus.Infof("%-28s %6d samples %5.1f%% %s",
+			count.WaitEvent,
+			count.Samples,
+			count.Share*100,
+			strings.Repeat("#", int(count.Share*50+0.5)),
+		)
+	}
+}
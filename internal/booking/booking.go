@@ -2,7 +2,9 @@ package booking
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -11,34 +13,60 @@ import (
 	pgconn2 "github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
 	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
 	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
 	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
-	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+	pgserverstats "github.com/soumya-codes/airline-reservation-poc/internal/postgres/serverstats"
+	pgstatements "github.com/soumya-codes/airline-reservation-poc/internal/postgres/statements"
+	pgtrace "github.com/soumya-codes/airline-reservation-poc/internal/postgres/trace"
 	"github.com/soumya-codes/airline-reservation-poc/internal/store"
-)
-
-const (
-	totalRows   = 30
-	seatsPerRow = 6
-	aisleCol    = 2
+	"github.com/soumya-codes/airline-reservation-poc/internal/telemetry"
 )
 
 type booking struct {
 	passengerName string
-	seatNumber    int32
 	seatId        string
 }
 
 type bookingStatus struct {
 	booking
-	err error
+	passengerID int32
+	// attempt is the number of the attempt, starting at 1.
+	attempt int
+	err     error
+	// savepointRetries is the number of failed claims rolled back to a savepoint during the attempt.
+	savepointRetries int
+	// lockHold is the time the claimed seats were held during the attempt.
+	lockHold time.Duration
+	// roundTrips is the number of round trips to the server made during the attempt.
+	roundTrips int64
+	// latency is the time from the booking request to the successful booking, including all failed attempts.
+	latency time.Duration
+	// acquireWait is the time the attempt waited for a pool connection.
+	acquireWait time.Duration
+	// attemptLatency is the time the attempt spent on its connection, from the start of its transaction to its end.
+	attemptLatency time.Duration
+	// commitLatency is the time the commit of the attempt took, 0 if it wasn't committed or can't be told apart from the claim.
+	commitLatency time.Duration
+	// phase is the phase the attempt got to, the phase it failed in if it failed.
+	phase errorPhase
 }
 
 func BookSeats(ctx context.Context, config *config.Config) error {
+	// Record the spans of the run, the root span is carried by ctx to the booking tasks and their queries
+	spans := newSpanTracer(config)
+	ctx, runSpan := startRunSpan(ctx, config, spans)
+	defer runSpan.End()
+
 	// Initiate connection to the database
-	pgConfig := config.PostgresConfig
-	conn, err := pgconn.NewConnection(pgConfig)
+	pgConfig := *config.PostgresConfig
+	if spans != nil {
+		pgConfig.Tracer = telemetry.NewQueryTracer(spans)
+	}
+
+	conn, err := pgconn.NewConnection(&pgConfig)
 	if err != nil {
 		return fmt.Errorf("error connecting to database to start the bookings: %w", err)
 	}
@@ -57,24 +85,81 @@ func BookSeats(ctx context.Context, config *config.Config) error {
 		return fmt.Errorf("error getting passengers: %w", err)
 	}
 
-	// Get the next available tripID
-	tripID, err := GetNextAvailableTrip(ctx, q)
+	// Get the next available tripID, of the cabin layout if one is configured
+	var tripID int32
+	if config.CabinLayout != "" {
+		tripID, err = GetNextAvailableTripWithCabinLayout(ctx, q, config.CabinLayout)
+	} else {
+		tripID, err = GetNextAvailableTrip(ctx, q)
+	}
 	if err != nil {
 		return fmt.Errorf("error getting next available tripID: %s", err.Error())
 	}
 
+	// Get the cabin layout of the trip, the seat map and the heatmap are rendered over it
+	layout, err := GetTripCabinLayout(ctx, q, tripID)
+	if err != nil {
+		return fmt.Errorf("error getting cabin layout of trip %d: %w", tripID, err)
+	}
+
 	// Mark the tripID, so it's not considered for booking again
 	err = MarkTripForBooking(ctx, q, tripID)
 	if err != nil {
 		return fmt.Errorf("error marking tripID as booked: %w", err)
 	}
 
+	// Tag the pooled connections with the run ID, so their backends can be found in pg_stat_activity
+	runID := newRunID()
+	runSpan.SetAttributes(telemetry.String("run.id", runID), telemetry.Int("trip.id", int64(tripID)))
+	poolConfig := pgConfig
+	poolConfig.ApplicationName = pgactivity.RunApplicationName(runID)
+
+	// Trace the statements sent on the pooled connections
+	queryTrace := newQueryTrace(config)
+	if queryTrace != nil {
+		poolConfig.Tracer = pgconn.Tracers(pgtrace.NewTracer(queryTrace), pgConfig.Tracer)
+	}
+
 	// Create a connection pool of size maxConn
-	pool, err := pgpool.NewConnectionPool(pgConfig, config.MaxConn)
+	pool, err := pgpool.NewConnectionPool(&poolConfig, config.MaxConn, config.PoolPolicy)
 	if err != nil {
 		return fmt.Errorf("error creating connection pool: %s", err.Error())
 	}
 
+	// Close the pool if the run fails to start, closing the pool again after the run is a no-op
+	defer func() {
+		_ = pool.Close()
+	}()
+
+	recorder, err := startRunMetrics(config, pool)
+	if err != nil {
+		return err
+	}
+
+	// Zero the pool gauges if the run fails to start, so they don't keep reading the pool
+	defer recorder.stop()
+
+	stop, err := startActivitySampler(ctx, config, runID)
+	if err != nil {
+		return err
+	}
+
+	// Stop sampling if the run fails to start, the samples are taken once after the run
+	stopSampler := sync.OnceValue(stop)
+	defer stopSampler()
+
+	stopLockGraph, err := startLockGraphCollector(ctx, config, runID)
+	if err != nil {
+		return err
+	}
+
+	// Explain the queries of the lock strategy against the trip, before any of its seats is booked
+	plans := explainRun(ctx, config, conn, runID, tripID, passengers)
+
+	// Snapshot the server statistics, the snapshot after the run is taken once the pool is closed
+	statsBefore, statsOK := snapshotServerStats(ctx, conn)
+	statementStats := resetStatementStats(ctx, config, conn)
+
 	start := time.Now()
 
 	bks := make(chan bookingStatus, len(passengers))
@@ -86,15 +171,7 @@ func BookSeats(ctx context.Context, config *config.Config) error {
 		passenger := passenger // Not necessary for Golang versions >= 1.22
 		go func() {
 			defer wg.Done()
-			bookSeatTask(
-				ctx,
-				tripID,
-				passenger,
-				pool,
-				config.LockStrategy,
-				config.TxIsolation,
-				bks,
-				config.MaxRetries)
+			bookSeatTask(ctx, config, tripID, passenger, pool, queryTrace, bks)
 		}()
 	}
 
@@ -104,21 +181,103 @@ func BookSeats(ctx context.Context, config *config.Config) error {
 		close(bks)
 	}()
 
-	// Get the results from the channels and store them in the bookings and reservation slice
+	// Get the results from the channels and store them in the bookings slice
 	bookings := make([]string, 0)
-	reservations := make([]booking, int32(len(passengers)))
+	claims := claimStats{passengers: len(passengers)}
+	taxonomy := newErrorTaxonomy()
+	contention := make(seatContention)
+	history := newExperimentHistory(config, runID)
+	var latency latencyStats
+	// The last attempt of every passenger
+	outcomes := make(map[int32]bookingStatus, len(passengers))
+	// The seats claimed by every attempt, including the failed ones
+	seats := make(map[attemptKey]string, len(passengers))
 	// Loop through the bookings channel to get booking info/error, the loop ends when bks channel is closed
 	for bk := range bks {
+		claims.add(bk)
+		taxonomy.add(bk)
+		contention.add(bk)
+		latency.add(bk)
+		recorder.record(bk)
+		history.add(bk)
+		outcomes[bk.passengerID] = bk
+		if bk.seatId != "" {
+			seats[attemptKey{passengerID: bk.passengerID, attempt: bk.attempt}] = bk.seatId
+		}
 		if bk.err != nil {
 			bookings = append(bookings, fmt.Sprintf("ERROR: couldn't book seat: %s", bk.err.Error()))
 		} else {
 			bookings = append(bookings, fmt.Sprintf("Seat: %s is booked for passenger: %s", bk.seatId, bk.passengerName))
-			reservations[(bk.seatNumber-1)%int32(len(passengers))] = bk.booking
 		}
 	}
 
 	elapsed := time.Since(start)
-	defer printBookingAndReservationDetails(reservations, bookings, tripID, elapsed)
+	taxonomy.addOutcomes(outcomes)
+	fairness := measureFairness(pool.AcquisitionOrder())
+	samples := stopSampler()
+	observations := runObservations{
+		plans:      plans,
+		traced:     traceBookings(samples, outcomes),
+		waitEvents: pgactivity.WaitEventHistogram(samples),
+	}
+
+	observations.lockGraph, err = writeLockGraph(config, runID, stopLockGraph(), seats)
+	if err != nil {
+		logrus.WithError(err).Error("error writing lock wait-for graph")
+	}
+
+	observations.queryTrace, err = writeQueryTrace(config, runID, queryTrace)
+	if err != nil {
+		logrus.WithError(err).Error("error writing query trace")
+	}
+
+	heatmap, err := writeHeatmap(config, runID, layout, contention)
+	if err != nil {
+		logrus.WithError(err).Error("error writing seat contention heatmap")
+	}
+
+	runSpan.SetAttributes(telemetry.Int("booking.bookings", int64(claims.bookings)), telemetry.Int("booking.attempts", int64(claims.attempts)))
+	runSpan.End()
+	observations.spans, err = writeSpans(config, runID, spans)
+	if err != nil {
+		logrus.WithError(err).Error("error writing spans")
+	}
+
+	// Close the pool, so the backends of the run exit and flush their statistics
+	if err := pool.Close(); err != nil {
+		logrus.WithError(err).Error("error closing connection pool")
+	}
+	recorder.stop()
+
+	if statsOK {
+		observations.serverStats = serverStatsDelta(ctx, conn, runID, statsBefore)
+	}
+
+	if statementStats {
+		observations.statements = readStatementStats(ctx, conn)
+	}
+
+	// Read the seats of the trip back, the run may have hit its deadline
+	seatMap, err := GetTripSeatMap(context.WithoutCancel(ctx), q, tripID, layout)
+	if err != nil {
+		logrus.WithError(err).Error("error getting seat map")
+	}
+
+	seatMapFiles, err := writeSeatMap(config, runID, seatMap)
+	if err != nil {
+		logrus.WithError(err).Error("error writing seat map")
+	}
+
+	run := newRunReport(config, runID, tripID, layout, start, elapsed, latency, fairness, claims, taxonomy, observations.serverStats, seatMap)
+	reports, err := writeRunReport(config, run)
+	if err != nil {
+		logrus.WithError(err).Error("error writing run report")
+	}
+
+	if err := history.record(context.WithoutCancel(ctx), conn, run); err != nil {
+		logrus.WithError(err).Error("error recording experiment history")
+	}
+	defer printBookingAndReservationDetails(config, runID, reports, layout, seatMap, seatMapFiles, bookings, tripID, elapsed, latency, fairness, claims, taxonomy, contention, heatmap, observations)
 
 	return nil
 }
@@ -145,6 +304,36 @@ func GetNextAvailableTrip(ctx context.Context, q *store.Queries) (int32, error)
 	return tripID, nil
 }
 
+// GetNextAvailableTripWithCabinLayout retrieves the next available trip ID of the cabin layout from the database.
+func GetNextAvailableTripWithCabinLayout(ctx context.Context, q *store.Queries, layout string) (int32, error) {
+	tripID, err := q.GetNextAvailableTripWithCabinLayout(ctx, layout)
+	if err != nil {
+		return -1, fmt.Errorf("error getting a trip of the %s cabin layout: %w", layout, err)
+	}
+
+	return tripID, nil
+}
+
+// GetTripCabinLayout retrieves the cabin layout of a trip from the database.
+func GetTripCabinLayout(ctx context.Context, q *store.Queries, tripID int32) (cabin.Layout, error) {
+	layout, err := q.GetTripCabinLayout(ctx, tripID)
+	if err != nil {
+		return cabin.Layout{}, fmt.Errorf("error getting the cabin layout of the trip: %w", err)
+	}
+
+	return cabin.FromStore(layout), nil
+}
+
+// GetTripSeatMap retrieves the seats of a trip and the passengers they are assigned to, laid out over the cabin layout.
+func GetTripSeatMap(ctx context.Context, q *store.Queries, tripID int32, layout cabin.Layout) (*cabin.SeatMap, error) {
+	seats, err := q.GetTripSeats(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the seats of the trip: %w", err)
+	}
+
+	return cabin.NewSeatMap(tripID, layout, seats)
+}
+
 // MarkTripForBooking marks a trip as booked in the database.
 func MarkTripForBooking(ctx context.Context, q *store.Queries, tripID int32) error {
 	// Mark the trip as booked
@@ -156,148 +345,156 @@ func MarkTripForBooking(ctx context.Context, q *store.Queries, tripID int32) err
 	return nil
 }
 
+// runObservations is what was observed on the server while the seats were booked.
+type runObservations struct {
+	// traced are the backends the failed and slowest bookings were last seen on.
+	traced []tracedBooking
+	// waitEvents is the histogram of the wait events of the backends of the run.
+	waitEvents []pgactivity.WaitEventCount
+	// lockGraph is the wait-for graph of the lock waits.
+	lockGraph lockGraphSummary
+	// serverStats is the change of the server statistics over the run, nil if they couldn't be read.
+	serverStats *pgserverstats.Counters
+	// statements are the execution statistics of the queries of the run, by sqlc query name.
+	statements []pgstatements.Statement
+	// plans are the plans of the lock strategy's queries, explained before the run.
+	plans []strategyPlans
+	// queryTrace is the trace of the statements sent by the bookings.
+	queryTrace queryTraceSummary
+	// spans are the spans of the booking flows and their queries.
+	spans spanSummary
+}
+
+func printObservations(observations runObservations, claims claimStats) {
+	printServerStats(observations.serverStats, claims)
+	printStatementStats(observations.statements)
+	for _, plans := range observations.plans {
+		printPlans(plans)
+	}
+	printWaitEvents(observations.waitEvents)
+	printTracedBookings(observations.traced)
+	printLockGraph(observations.lockGraph)
+	printQueryTrace(observations.queryTrace)
+	printSpans(observations.spans)
+}
+
 // bookSeatTask handles the booking of a seat for a passenger.
+// A connection is acquired from the pool for every attempt and released before backing off,
+// so a retry queues up again and is subject to the pool's acquisition policy.
 func bookSeatTask(ctx context.Context,
+	config *config.Config,
 	tripID int32,
 	passenger store.Passenger,
 	pool *pgpool.ConnectionPool,
-	seatLockStrategy bookingseat.LockStrategy,
-	isolationLevel pgtx.IsolationLevel,
+	trace *pgtrace.Buffer,
 	bs chan<- bookingStatus,
-	maxRetries int,
 ) {
-	// Acquire a connection from the pool
-	conn := pool.Acquire()
-	defer pool.Release(conn)
+	requested := time.Now()
+
+	// The task's span ends with the outcome of its last attempt
+	ctx, span := telemetry.Start(ctx, "bookSeatTask",
+		telemetry.Int("passenger.id", int64(passenger.Identifier)),
+		telemetry.Int("trip.id", int64(tripID)),
+	)
+	var last bookingStatus
+	defer func() {
+		span.SetAttributes(telemetry.Int("booking.attempts", int64(last.attempt)))
+		endBookingSpan(span, last)
+	}()
+	send := func(status bookingStatus) {
+		last = status
+		bs <- status
+	}
 
-	for retry := 1; retry <= maxRetries; retry++ {
-		// Start a transaction
-		tx, err := pgtx.BeginTxWithIsolationLevel(ctx, conn, isolationLevel)
+	for retry := 1; retry <= config.MaxRetries; retry++ {
+		// Acquire a connection from the pool
+		acquireStart := time.Now()
+		_, acquireSpan := telemetry.Start(ctx, "pool.acquire", telemetry.Int("booking.attempt", int64(retry)))
+		conn, err := pool.Acquire(ctx, attemptPriority(config.PriorityClass, passenger.Identifier, retry))
+		acquireSpan.RecordError(err)
+		acquireSpan.End()
+		trace.Add(clientEvent(pgtrace.AcquireWait, conn, passenger.Identifier, retry, acquireStart, err))
 		if err != nil {
-			retriesLeft := handleRetries(
-				retry,
-				maxRetries,
-				booking{passengerName: passenger.Name},
-				fmt.Errorf("retry %d/%d failed: error starting transaction: %w",
+			send(bookingStatus{
+				err: fmt.Errorf("retry %d/%d failed: error acquiring connection for passenger %s: %w",
 					retry,
-					maxRetries,
+					config.MaxRetries,
+					passenger.Name,
 					err,
 				),
-				bs,
-			)
-			if retriesLeft {
-				continue
-			}
-
-			return
-		}
-
-		// Get queries instance to execute requests in the transaction
-		q := store.New(conn).WithTx(tx)
-
-		// Get the next available seat
-		seat, err := seatLockStrategy(ctx, q, tripID)
-		if err != nil {
-			txErrMsg := fmt.Sprintf("retry %d/%d failed: error getting next available seat for passenger %s",
-				retry,
-				maxRetries,
-				passenger.Name,
-			)
-
-			err = handleTransactionError(ctx, tx, txErrMsg, err)
-			retriesLeft := handleRetries(
-				retry,
-				maxRetries,
-				booking{passengerName: passenger.Name},
-				err,
-				bs)
-			if retriesLeft {
-				continue
-			}
+				booking:     booking{passengerName: passenger.Name},
+				passengerID: passenger.Identifier,
+				attempt:     retry,
+				acquireWait: time.Since(acquireStart),
+				phase:       phaseAcquire,
+			})
 
 			return
 		}
 
-		// Book a seat for the passenger
-		_, err = q.BookSeat(ctx, store.BookSeatParams{PassengerID: passenger.Identifier, Identifier: seat.ID})
-		if err != nil {
-			txErrMsg := fmt.Sprintf("retry %d/%d failed: error booking seat %s for passenger %s",
-				retry,
-				maxRetries,
-				seat.SeatID,
-				passenger.Name,
-			)
-
-			err = handleTransactionError(ctx, tx, txErrMsg, err)
-			retriesLeft := handleRetries(
-				retry,
-				maxRetries,
-				booking{passengerName: passenger.Name, seatId: seat.SeatID, seatNumber: seat.ID},
-				err,
-				bs)
-			if retriesLeft {
-				continue
+		acquireWait := time.Since(acquireStart)
+		status := bookSeatAttempt(ctx, config, conn, tripID, passenger, retry)
+		status.acquireWait = acquireWait
+		pool.Release(conn)
+		if status.err != nil {
+			last = status
+			if allRetriesExhausted(retry, config.MaxRetries) {
+				handleRetries(retry, config.MaxRetries, status, bs)
+				return
 			}
 
-			return
-		}
-
-		err = tx.Commit(ctx)
-		if err != nil {
-			retriesLeft := handleRetries(
-				retry,
-				maxRetries,
-				booking{
-					passengerName: passenger.Name,
-					seatId:        seat.SeatID,
-					seatNumber:    seat.ID,
-				},
-				fmt.Errorf("retry %d/%d failed: error committing transaction for passenger: %s, %w",
-					retry,
-					maxRetries,
-					passenger.Name,
-					err,
-				),
-				bs,
-			)
-			if retriesLeft {
-				continue
-			}
+			// Only a back-off followed by a retry is traced
+			backoffStart := time.Now()
+			_, backoffSpan := telemetry.Start(ctx, "backoff", telemetry.Int("booking.attempt", int64(retry)))
+			handleRetries(retry, config.MaxRetries, status, bs)
+			backoffSpan.End()
+			trace.Add(clientEvent(pgtrace.Backoff, nil, passenger.Identifier, retry, backoffStart, nil))
 
-			return
+			continue
 		}
 
-		bs <- bookingStatus{
-			err: nil,
-			booking: booking{
-				passengerName: passenger.Name,
-				seatId:        seat.SeatID,
-				seatNumber:    seat.ID,
-			},
-		}
+		status.latency = time.Since(requested)
+		send(status)
 
 		return
 	}
 }
 
-// handleTransactionError handles transaction rollback and sends the error to the result channel.
-func handleTransactionError(ctx context.Context, tx pgx.Tx, msg string, err error) error {
-	errMsg := fmt.Errorf("%s: %w", msg, err)
-	if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
-		errMsg = fmt.Errorf("%s: %w, error rolling back transaction: %w", msg, err, rollbackErr)
+// bookSeatAttempt makes a single attempt to book a seat for the passenger in a transaction on conn.
+// On failure, the returned status carries the error along with whatever is known about the booking at that point.
+func bookSeatAttempt(ctx context.Context,
+	config *config.Config,
+	conn *pgx.Conn,
+	tripID int32,
+	passenger store.Passenger,
+	retry int,
+) bookingStatus {
+	// Attribute the statements of the attempt to the passenger in the query trace and in the spans
+	ctx = pgtrace.WithAttempt(ctx, passenger.Identifier, retry)
+	ctx, span := startAttemptSpan(ctx, config, tripID, passenger.Identifier, retry)
+	status := bookingStatus{booking: booking{passengerName: passenger.Name}, passengerID: passenger.Identifier, attempt: retry, phase: phaseBegin}
+	tagBooking(ctx, config, conn, passenger.Identifier, retry)
+	roundTrips := pgconn.RoundTrips(conn)
+	attemptStart := time.Now()
+
+	err := claimSeatInBookingMode(ctx, config, conn, tripID, passenger, &status)
+	status.attemptLatency = time.Since(attemptStart)
+	status.roundTrips = pgconn.RoundTrips(conn) - roundTrips
+
+	if err != nil {
+		status.err = fmt.Errorf("retry %d/%d failed: %w", retry, config.MaxRetries, err)
 	}
+	endBookingSpan(span, status)
 
-	return errMsg
+	return status
 }
 
 // handleRetries checks if the max retries are exhausted and sends error to the result channel.
-func handleRetries(retry int, maxRetries int, bk booking, err error, bs chan<- bookingStatus) bool {
-	bs <- bookingStatus{
-		err:     err,
-		booking: bk,
-	}
+func handleRetries(retry int, maxRetries int, status bookingStatus, bs chan<- bookingStatus) bool {
+	bs <- status
 
-	if pgErr, ok := err.(*pgconn2.PgError); ok && pgErr.Code == "40P01" {
+	var pgErr *pgconn2.PgError
+	if errors.As(status.err, &pgErr) && pgErr.Code == "40P01" {
 		// Deadlock detected
 		// This value is tightly coupled with query execution time and "deadlock_timeout" value set in postgresql.conf
 		// TODO: Implement a better way to handle deadlocks
@@ -322,9 +519,46 @@ func allRetriesExhausted(retry int, maxRetries int) bool {
 }
 
 // print booking process(successful and failed tx) details, including the final reservation details.
-func printBookingAndReservationDetails(reservations []booking, bookings []string, tripID int32, elapsedTime time.Duration) {
+func printBookingAndReservationDetails(config *config.Config,
+	runID string,
+	reports []string,
+	layout cabin.Layout,
+	seatMap *cabin.SeatMap,
+	seatMapFiles []string,
+	bookings []string,
+	tripID int32,
+	elapsedTime time.Duration,
+	latency latencyStats,
+	fairness Fairness,
+	claims claimStats,
+	taxonomy errorTaxonomy,
+	contention seatContention,
+	heatmap string,
+	observations runObservations,
+) {
 	logrus.Infof("Total time taken to book the seats for trip-id: %d is %v", tripID, elapsedTime)
 
+	// Print the settings the run was executed with.
+	printRunConfig(config, runID)
+	for _, report := range reports {
+		logrus.Infof("Run report written to %s", report)
+	}
+
+	// Print the latency of the successful bookings and the throughput of the run.
+	printLatency(latency, elapsedTime)
+
+	// Print how closely the pool handed over its connections in the arrival order of the acquire requests.
+	printFairness(config.PoolPolicy, fairness)
+
+	// Print the retries and the time the seats were held for.
+	printClaimStats(config, claims)
+
+	// Print the failed attempts by SQLSTATE, phase and attempt number, and how many passengers were booked after failing.
+	printErrorTaxonomy(taxonomy)
+
+	// Print what the backends of the run were doing, as observed on the server.
+	printObservations(observations, claims)
+
 	fmt.Print("\n\n")
 
 	// Print the booking details, this contains details of the successful, overlapping and failed bookings/transactions.
@@ -333,11 +567,39 @@ func printBookingAndReservationDetails(reservations []booking, bookings []string
 	fmt.Print("\n\n")
 
 	// Print the final seat reservation details.
-	printReservationDetails(reservations)
+	printReservationDetails(seatMap, seatMapFiles)
+
+	fmt.Print("\n")
+
+	// Print how often every seat was returned by the lock strategy.
+	printHeatmap(layout, contention, heatmap)
 
 	fmt.Print("\n\n\n\n")
 }
 
+func printRunConfig(config *config.Config, runID string) {
+	pgConfig := config.PostgresConfig
+	logrus.Infof("Run configuration: run id: %s, isolation level: %s, lock strategy: %s, pool size: %d, pool policy: %s, max retries: %d, savepoint retries: %d",
+		runID,
+		config.TxIsolation,
+		bookingseat.Name(config.LockStrategy),
+		config.MaxConn,
+		config.PoolPolicy,
+		config.MaxRetries,
+		config.SavepointRetries,
+	)
+
+	statementCache := "default"
+	if pgConfig.StatementCacheCapacity > 0 {
+		statementCache = fmt.Sprint(pgConfig.StatementCacheCapacity)
+	}
+	logrus.Infof("Connection settings: query exec mode: %s, statement cache capacity: %s, session settings: %s",
+		pgConfig.QueryExecMode,
+		statementCache,
+		pgConfig.SessionSettings,
+	)
+}
+
 func printBookingDetails(bookings []string) {
 	logrus.Info("Booking details:")
 	for _, booking := range bookings {
@@ -349,31 +611,22 @@ func printBookingDetails(bookings []string) {
 	}
 }
 
-func printReservationDetails(reservations []booking) {
-	logrus.Info("Final seat reservation details:")
-	for _, reservation := range reservations {
-		if reservation.passengerName != "" {
-			logrus.Infof("Seat: %s, is assigned to Passenger: %s", reservation.seatId, reservation.passengerName)
-		}
+func printReservationDetails(seatMap *cabin.SeatMap, files []string) {
+	if seatMap == nil {
+		return
 	}
 
-	for col := 0; col < seatsPerRow; col++ {
-		for row := 0; row < totalRows; row++ {
-			index := row*seatsPerRow + col
-			if reservations[index].passengerName == "" {
-				fmt.Print(".")
-			} else {
-				fmt.Print("x")
-			}
-			// Print a space after each seat except the last one in the column
-			if col == aisleCol && row == totalRows-1 {
-				fmt.Print("\n\n") // Print an extra space for the aisle
-			} else if row != totalRows-1 {
-				fmt.Print("  ")
-			}
+	logrus.Infof("Final seat reservation details, cabin layout: %s:", seatMap.Layout)
+	for _, seat := range seatMap.Seats {
+		if seat.IsBooked() {
+			logrus.Infof("Seat: %s, is assigned to Passenger: %s", seat.ID, seat.PassengerName)
 		}
+	}
+	for _, file := range files {
+		logrus.Infof("Seat map written to %s", file)
+	}
 
-		// Move to the next column
-		fmt.Println()
+	if err := seatMap.WriteTerminal(os.Stdout); err != nil {
+		logrus.WithError(err).Error("error printing seat map")
 	}
 }
@@ -2,13 +2,22 @@ package booking
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/soumya-codes/airline-reservation-poc/config"
 	"github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
 	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
 )
 
 func TestBookSeats(t *testing.T) {
@@ -61,3 +70,620 @@ func TestBookSeats(t *testing.T) {
 		}
 	}
 }
+
+// bookSeats books the seats of the next available trip with the options and the run report written to dir, and
+// returns the report of the run. It fails the test if the booking fails or none of the passengers is booked.
+func bookSeats(t *testing.T, dir string, opts ...config.Option) *report.Run {
+	t.Helper()
+
+	cfg := config.NewConfig(append(opts, config.WithReport(dir))...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	if err := BookSeats(ctx, cfg); err != nil {
+		t.Fatalf("BookSeats() error = %v", err)
+	}
+
+	// The runs of a test may share dir, the run just made is the last one to start
+	runs, err := report.LoadAll([]string{dir})
+	if err != nil || len(runs) == 0 {
+		t.Fatalf("LoadAll(%s) = %d runs, error %v, want the report of the run", dir, len(runs), err)
+	}
+
+	run := runs[len(runs)-1]
+	if o := run.Outcomes; o.Bookings == 0 || o.Bookings > o.Passengers || o.Attempts < o.Bookings {
+		t.Errorf("outcomes = %+v, want some of the passengers booked", o)
+	}
+
+	return run
+}
+
+// assertFiles fails the test if no file of dir matches one of the patterns.
+func assertFiles(t *testing.T, dir string, patterns ...string) {
+	t.Helper()
+
+	for _, pattern := range patterns {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) == 0 {
+			t.Errorf("no %s written to %s", pattern, dir)
+		}
+	}
+}
+
+func TestBookSeatsAcquisitionPolicies(t *testing.T) {
+	policies := []struct {
+		policy            pgpool.AcquisitionPolicy
+		priorityClass     func(passengerID int32, attempt int) pgpool.Priority
+		priorityClassName string
+	}{
+		{policy: pgpool.Unordered, priorityClassName: "None"},
+		{policy: pgpool.FIFO, priorityClassName: "None"},
+		{policy: pgpool.LIFO, priorityClassName: "None"},
+		{policy: pgpool.PriorityFIFO, priorityClass: RetriesFirst, priorityClassName: "RetriesFirst"},
+		// Every 10th passenger is a frequent flyer.
+		{
+			policy:            pgpool.PriorityFIFO,
+			priorityClass:     FrequentFlyersFirst(10, 20, 30, 40, 50, 60, 70, 80, 90, 100),
+			priorityClassName: "FrequentFlyersFirst",
+		},
+	}
+
+	poolSize := 5
+	retries := 3
+
+	for _, policy := range policies {
+		t.Run(fmt.Sprintf("PoolPolicy=%s_PriorityClass=%s_PoolSize=%d_Retries=%d",
+			policy.policy, policy.priorityClassName, poolSize, retries),
+			func(t *testing.T) {
+				run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLockSkipped),
+					config.WithMaxRetries(retries),
+					config.WithPoolPolicy(policy.policy),
+					config.WithPriorityClass(policy.priorityClass),
+				)
+
+				if run.Config.PoolPolicy != string(policy.policy) {
+					t.Errorf("pool policy = %q, want %q", run.Config.PoolPolicy, policy.policy)
+				}
+
+				f := run.Fairness
+				if f.KendallTau < 0 || f.KendallTau > 1 || f.MeanDisplacement > float64(f.MaxDisplacement) {
+					t.Errorf("fairness = %+v, want a kendall-tau distance in [0, 1] and the mean displacement below the max", f)
+				}
+				t.Logf("Pool acquisition policy: %s, fairness: kendall-tau distance %.3f (%d inversions), mean displacement %.2f, max displacement %d",
+					policy.policy, f.KendallTau, f.Inversions, f.MeanDisplacement, f.MaxDisplacement)
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsSessionSettings(t *testing.T) {
+	// Every setting with a short and a long timeout
+	sessionSettings := []pgconn.SessionSettings{
+		{LockTimeout: 10 * time.Millisecond},
+		{LockTimeout: 100 * time.Millisecond},
+		{StatementTimeout: 50 * time.Millisecond},
+		{StatementTimeout: 500 * time.Millisecond},
+		{DeadlockTimeout: 5 * time.Millisecond},
+		{DeadlockTimeout: 200 * time.Millisecond},
+		{IdleInTransactionSessionTimeout: 100 * time.Millisecond},
+		{IdleInTransactionSessionTimeout: time.Second},
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, settings := range sessionSettings {
+		t.Run(fmt.Sprintf("SessionSettings=%s_PoolSize=%d_Retries=%d", settings, poolSize, retries),
+			func(t *testing.T) {
+				run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+					config.WithMaxRetries(retries),
+					config.WithSessionSettings(settings),
+				)
+
+				if run.Config.SessionSettings != settings.String() {
+					t.Errorf("session settings = %q, want %q", run.Config.SessionSettings, settings)
+				}
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsSavepointRetries(t *testing.T) {
+	isolationLevels := []pgtx.IsolationLevel{
+		pgtx.ReadCommitted,
+		pgtx.RepeatableRead,
+		pgtx.Serializable,
+	}
+
+	savepointRetries := []int{0, 3}
+
+	poolSize := 50
+	retries := 3
+
+	for _, isolationLevel := range isolationLevels {
+		for _, spRetries := range savepointRetries {
+			t.Run(fmt.Sprintf("IsolationLevel=%v_SavepointRetries=%d_PoolSize=%d_Retries=%d",
+				isolationLevel, spRetries, poolSize, retries),
+				func(t *testing.T) {
+					run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+						config.WithTxIsolation(isolationLevel),
+						config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+						config.WithMaxRetries(retries),
+						config.WithSavepointRetries(spRetries),
+					)
+
+					if savepoints := strings.HasPrefix(run.Config.ClaimMode, "savepoint"); savepoints != (spRetries > 0) {
+						t.Errorf("claim mode = %q with %d savepoint retries, want savepoints %v", run.Config.ClaimMode, spRetries, spRetries > 0)
+					}
+					if spRetries == 0 && run.Outcomes.SavepointRetries != 0 {
+						t.Errorf("savepoint retries = %d, want none without savepoints", run.Outcomes.SavepointRetries)
+					}
+					t.Logf("Claim mode: %s, isolation level: %s, transaction retries: %d, savepoint retries: %d, seat hold time: mean %v, max %v",
+						run.Config.ClaimMode, isolationLevel, run.Outcomes.TransactionRetries, run.Outcomes.SavepointRetries,
+						run.Outcomes.MeanLockHold, run.Outcomes.MaxLockHold)
+				})
+
+			// Sleep for 3 seconds to allow the connections to be released
+			time.Sleep(3 * time.Second)
+		}
+	}
+}
+
+func TestBookSeatsQueryExecModes(t *testing.T) {
+	execModes := []struct {
+		mode pgx.QueryExecMode
+		// statementCacheCapacity of 0 keeps the pgx default
+		statementCacheCapacity int
+	}{
+		{mode: pgx.QueryExecModeCacheStatement},
+		// A cache that holds a single statement keeps evicting and re-preparing the booking queries.
+		{mode: pgx.QueryExecModeCacheStatement, statementCacheCapacity: 1},
+		{mode: pgx.QueryExecModeCacheDescribe},
+		{mode: pgx.QueryExecModeCacheDescribe, statementCacheCapacity: 1},
+		{mode: pgx.QueryExecModeDescribeExec},
+		{mode: pgx.QueryExecModeExec},
+		{mode: pgx.QueryExecModeSimpleProtocol},
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, execMode := range execModes {
+		t.Run(fmt.Sprintf("QueryExecMode=%s_StatementCacheCapacity=%d_PoolSize=%d_Retries=%d",
+			execMode.mode, execMode.statementCacheCapacity, poolSize, retries),
+			func(t *testing.T) {
+				opts := []config.Option{config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLockSkipped),
+					config.WithMaxRetries(retries),
+					config.WithQueryExecMode(execMode.mode),
+				}
+				if execMode.statementCacheCapacity > 0 {
+					opts = append(opts, config.WithStatementCacheCapacity(execMode.statementCacheCapacity))
+				}
+				run := bookSeats(t, t.TempDir(), opts...)
+
+				if run.Config.QueryExecMode != execMode.mode.String() {
+					t.Errorf("query exec mode = %q, want %q", run.Config.QueryExecMode, execMode.mode)
+				}
+				t.Logf("Query exec mode: %s, statement cache capacity: %d, throughput: %.1f bookings/s, booking p99: %v, attempt p99: %v",
+					execMode.mode, execMode.statementCacheCapacity, run.Throughput, run.Latency.Booking.P99, run.Latency.Attempt.P99)
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsBookingModes(t *testing.T) {
+	isolationLevels := []pgtx.IsolationLevel{
+		pgtx.ReadCommitted,
+		pgtx.RepeatableRead,
+		pgtx.Serializable,
+	}
+
+	lockStrategies := []struct {
+		strategy     seat.LockStrategy
+		strategyName string
+	}{
+		{strategy: seat.GetSeatWithNoLock, strategyName: "GetSeatWithNoLock"},
+		{strategy: seat.GetSeatWithSharedLock, strategyName: "GetSeatWithSharedLock"},
+		{strategy: seat.GetSeatWithSharedLockSkipped, strategyName: "GetSeatWithSharedLockSkipped"},
+		{strategy: seat.GetSeatWithExclusiveLock, strategyName: "GetSeatWithExclusiveLock"},
+		{strategy: seat.GetSeatWithExclusiveLockSkipped, strategyName: "GetSeatWithExclusiveLockSkipped"},
+	}
+
+	// Client-side booking is covered by TestBookSeats
+	bookingModes := []struct {
+		mode      config.BookingMode
+		claimMode string
+	}{
+		{mode: config.PipelinedBooking, claimMode: "pipelined"},
+		{mode: config.ServerSideBooking, claimMode: "server-side"},
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, isolationLevel := range isolationLevels {
+		for _, strategy := range lockStrategies {
+			for _, bookingMode := range bookingModes {
+				t.Run(fmt.Sprintf("IsolationLevel=%v_LockStrategy=%s_BookingMode=%s_PoolSize=%d_Retries=%d",
+					isolationLevel, strategy.strategyName, bookingMode.mode, poolSize, retries),
+					func(t *testing.T) {
+						run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+							config.WithTxIsolation(isolationLevel),
+							config.WithLockStrategy(strategy.strategy),
+							config.WithMaxRetries(retries),
+							config.WithBookingMode(bookingMode.mode),
+						)
+
+						// Every lock strategy has a counterpart in every booking mode, so none falls back to client-side booking
+						if run.Config.ClaimMode != bookingMode.claimMode {
+							t.Errorf("claim mode = %q, want %q", run.Config.ClaimMode, bookingMode.claimMode)
+						}
+						t.Logf("Round trips: %d, %.2f per attempt, %.2f per booking", run.Outcomes.RoundTrips,
+							float64(run.Outcomes.RoundTrips)/float64(run.Outcomes.Attempts), float64(run.Outcomes.RoundTrips)/float64(run.Outcomes.Bookings))
+					})
+
+				// Sleep for 3 seconds to allow the connections to be released
+				time.Sleep(3 * time.Second)
+			}
+		}
+	}
+}
+
+func TestBookSeatsActivitySampling(t *testing.T) {
+	sampleIntervals := []time.Duration{10 * time.Millisecond, 50 * time.Millisecond}
+
+	poolSize := 50
+	retries := 3
+
+	for _, interval := range sampleIntervals {
+		t.Run(fmt.Sprintf("SampleInterval=%v_PoolSize=%d_Retries=%d", interval, poolSize, retries),
+			func(t *testing.T) {
+				bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+					config.WithMaxRetries(retries),
+					config.WithActivitySampleInterval(interval),
+				)
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsLockGraph(t *testing.T) {
+	lockStrategies := []seat.LockStrategy{
+		seat.GetSeatWithExclusiveLock,
+		seat.GetSeatWithSharedLock,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, lockStrategy := range lockStrategies {
+		t.Run(fmt.Sprintf("LockStrategy=%s_PoolSize=%d_Retries=%d", seat.Name(lockStrategy), poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(lockStrategy),
+					config.WithMaxRetries(retries),
+					config.WithLockGraph(5*time.Millisecond, dir),
+				)
+
+				assertFiles(t, dir, "lock-graph-*.dot", "lock-graph-*.json")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsStatementStats(t *testing.T) {
+	lockStrategies := []seat.LockStrategy{
+		seat.GetSeatWithNoLock,
+		seat.GetSeatWithSharedLock,
+		seat.GetSeatWithSharedLockSkipped,
+		seat.GetSeatWithExclusiveLock,
+		seat.GetSeatWithExclusiveLockSkipped,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, lockStrategy := range lockStrategies {
+		t.Run(fmt.Sprintf("LockStrategy=%s_PoolSize=%d_Retries=%d", seat.Name(lockStrategy), poolSize, retries),
+			func(t *testing.T) {
+				bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(lockStrategy),
+					config.WithMaxRetries(retries),
+					config.WithStatementStats(),
+				)
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsExplainPlans(t *testing.T) {
+	lockStrategies := []seat.LockStrategy{
+		seat.GetSeatWithNoLock,
+		seat.GetSeatWithExclusiveLockSkipped,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, lockStrategy := range lockStrategies {
+		t.Run(fmt.Sprintf("LockStrategy=%s_PoolSize=%d_Retries=%d", seat.Name(lockStrategy), poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(lockStrategy),
+					config.WithMaxRetries(retries),
+					config.WithExplainPlans(dir),
+				)
+
+				assertFiles(t, dir, "explain-*.json", "explain-*.txt")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsQueryTrace(t *testing.T) {
+	bookingModes := []config.BookingMode{
+		config.ClientSideBooking,
+		config.PipelinedBooking,
+		config.ServerSideBooking,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, mode := range bookingModes {
+		t.Run(fmt.Sprintf("BookingMode=%s_PoolSize=%d_Retries=%d", mode, poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+					config.WithMaxRetries(retries),
+					config.WithBookingMode(mode),
+					config.WithQueryTrace(dir),
+				)
+
+				assertFiles(t, dir, "trace-*.json", "trace-*.txt", "trace-*.chrome.json")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsMetrics(t *testing.T) {
+	isolationLevels := []pgtx.IsolationLevel{
+		pgtx.ReadCommitted,
+		pgtx.Serializable,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, isolation := range isolationLevels {
+		t.Run(fmt.Sprintf("Isolation=%s_PoolSize=%d_Retries=%d", isolation, poolSize, retries),
+			func(t *testing.T) {
+				run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(isolation),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLockSkipped),
+					config.WithMaxRetries(retries),
+					config.WithMetrics("localhost:0"),
+				)
+
+				// Only the runs of this test record metrics, and each of them with labels of its own
+				labels := fmt.Sprintf(`isolation_level=%q,lock_strategy="GetSeatWithExclusiveLockSkipped",pool_size="%d"`, isolation, poolSize)
+				assertMetrics(t,
+					fmt.Sprintf("booking_attempts_total{%s} %d", labels, run.Outcomes.Attempts),
+					fmt.Sprintf("booking_successes_total{%s} %d", labels, run.Outcomes.Bookings),
+					fmt.Sprintf(`booking_pool_connections{%s,state="in_use"} 0`, labels),
+				)
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsSpans(t *testing.T) {
+	bookingModes := []config.BookingMode{
+		config.ClientSideBooking,
+		config.PipelinedBooking,
+		config.ServerSideBooking,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, mode := range bookingModes {
+		t.Run(fmt.Sprintf("BookingMode=%s_PoolSize=%d_Retries=%d", mode, poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+					config.WithMaxRetries(retries),
+					config.WithBookingMode(mode),
+					config.WithQueryTrace(dir),
+					config.WithSpans(dir),
+				)
+
+				assertFiles(t, dir, "spans-*.otlp.json")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsSeatHeatmap(t *testing.T) {
+	lockStrategies := []seat.LockStrategy{
+		seat.GetSeatWithNoLock,
+		seat.GetSeatWithExclusiveLockSkipped,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, lockStrategy := range lockStrategies {
+		t.Run(fmt.Sprintf("LockStrategy=%s_PoolSize=%d_Retries=%d", seat.Name(lockStrategy), poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(lockStrategy),
+					config.WithMaxRetries(retries),
+					config.WithSeatHeatmap(dir),
+				)
+
+				assertFiles(t, dir, "heatmap-*.svg")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsCabinLayouts(t *testing.T) {
+	layouts := []string{"narrow-body", "wide-body", "regional"}
+
+	poolSize := 50
+	retries := 3
+
+	for _, layout := range layouts {
+		t.Run(fmt.Sprintf("CabinLayout=%s_PoolSize=%d_Retries=%d", layout, poolSize, retries),
+			func(t *testing.T) {
+				dir := t.TempDir()
+				run := bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLockSkipped),
+					config.WithMaxRetries(retries),
+					config.WithCabinLayout(layout),
+					config.WithSeatMap(dir),
+				)
+
+				// The trip has a seat for every seat of its layout that is sold
+				if run.Config.CabinLayout != layout || run.SeatMap == nil || len(run.SeatMap.Seats) != run.SeatMap.Layout.Capacity() {
+					t.Errorf("cabin layout = %q, seat map %+v, want the seats of the %s layout", run.Config.CabinLayout, run.SeatMap, layout)
+				}
+
+				assertFiles(t, dir, "seatmap-*.txt", "seatmap-*.json")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func TestBookSeatsReport(t *testing.T) {
+	isolationLevels := []pgtx.IsolationLevel{
+		pgtx.ReadCommitted,
+		pgtx.RepeatableRead,
+		pgtx.Serializable,
+	}
+
+	poolSize := 50
+	retries := 3
+	// The runs share the report directory, so runs.csv collects a row per run
+	dir := t.TempDir()
+
+	for _, isolationLevel := range isolationLevels {
+		t.Run(fmt.Sprintf("IsolationLevel=%s_PoolSize=%d_Retries=%d", isolationLevel, poolSize, retries),
+			func(t *testing.T) {
+				run := bookSeats(t, dir, config.WithMaxConn(poolSize),
+					config.WithTxIsolation(isolationLevel),
+					config.WithLockStrategy(seat.GetSeatWithExclusiveLockSkipped),
+					config.WithMaxRetries(retries),
+				)
+
+				if run.Config.IsolationLevel != string(isolationLevel) {
+					t.Errorf("isolation level = %q, want the report of the %s run", run.Config.IsolationLevel, isolationLevel)
+				}
+
+				assertFiles(t, dir, "report-"+run.RunID+".json", "report-"+run.RunID+".md", "seats-"+run.RunID+".csv")
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "runs.csv"))
+	if err != nil {
+		t.Fatalf("error opening runs.csv: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(records) != 1+len(isolationLevels) {
+		t.Errorf("runs.csv = %d records, error %v, want the header and a row per run", len(records), err)
+	}
+}
+
+func TestBookSeatsExperimentHistory(t *testing.T) {
+	lockStrategies := []seat.LockStrategy{
+		seat.GetSeatWithExclusiveLock,
+		seat.GetSeatWithExclusiveLockSkipped,
+	}
+
+	poolSize := 50
+	retries := 3
+
+	for _, lockStrategy := range lockStrategies {
+		t.Run(fmt.Sprintf("LockStrategy=%s_PoolSize=%d_Retries=%d", seat.Name(lockStrategy), poolSize, retries),
+			func(t *testing.T) {
+				run := bookSeats(t, t.TempDir(), config.WithMaxConn(poolSize),
+					config.WithTxIsolation(pgtx.ReadCommitted),
+					config.WithLockStrategy(lockStrategy),
+					config.WithMaxRetries(retries),
+					config.WithExperimentHistory(),
+				)
+
+				conn, err := pgconn.NewConnection(config.DefaultConfig().PostgresConfig)
+				if err != nil {
+					t.Fatalf("error connecting to database: %v", err)
+				}
+				defer func() {
+					_ = pgconn.Close(conn)
+				}()
+
+				recorded, err := store.New(conn).GetExperimentRun(context.Background(), run.RunID)
+				if err != nil {
+					t.Fatalf("GetExperimentRun(%q) error = %v", run.RunID, err)
+				}
+
+				if recorded.LockStrategy != seat.Name(lockStrategy) || int(recorded.Bookings) != run.Outcomes.Bookings ||
+					int(recorded.Attempts) != run.Outcomes.Attempts || recorded.ServerSettingsHash == "" {
+					t.Errorf("recorded run = %+v, want the %s run with %d bookings of %d attempts and its server settings",
+						recorded, seat.Name(lockStrategy), run.Outcomes.Bookings, run.Outcomes.Attempts)
+				}
+			})
+
+		// Sleep for 3 seconds to allow the connections to be released
+		time.Sleep(3 * time.Second)
+	}
+}
@@ -0,0 +1,111 @@
+// Package cabin models the seating of an aircraft, as stored in the cabin_layout table, and the seat IDs of its seats.
+package cabin
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Layout is the seating of an aircraft. Seats are identified by their row number followed by their letter, e.g. "12C".
+type Layout struct {
+	Name string `json:"name"`
+	// Rows is the number of rows, numbered from 1.
+	Rows int `json:"rows"`
+	// Letters are the letters of the seats of a row, from the left window to the right window.
+	Letters string `json:"letters"`
+	// AisleAfter are the letters an aisle follows, e.g. "C" for a 3-3 layout.
+	AisleAfter string `json:"aisle_after"`
+	// Blocked are the seat IDs of the seats that aren't sold.
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+// NarrowBody is the layout of the trips that aren't inserted with another one, 30 rows of 3-3 seats.
+var NarrowBody = Layout{Name: "narrow-body", Rows: 30, Letters: "ABCDEF", AisleAfter: "C"}
+
+// FromStore returns the layout of a cabin_layout row.
+func FromStore(l store.CabinLayout) Layout {
+	return Layout{
+		Name:       l.Name,
+		Rows:       int(l.RowCount),
+		Letters:    l.SeatLetters,
+		AisleAfter: l.AisleAfter,
+		Blocked:    l.BlockedSeats,
+	}
+}
+
+// SeatID returns the ID of the seat in the row with the letter.
+func SeatID(row int, letter byte) string {
+	return strconv.Itoa(row) + string(letter)
+}
+
+// ParseSeatID returns the row and the letter of the seat ID.
+func ParseSeatID(id string) (row int, letter byte, err error) {
+	if len(id) < 2 {
+		return 0, 0, fmt.Errorf("invalid seat id %q", id)
+	}
+
+	letter = id[len(id)-1]
+	row, err = strconv.Atoi(id[:len(id)-1])
+	if err != nil || row < 1 || letter < 'A' || letter > 'Z' {
+		return 0, 0, fmt.Errorf("invalid seat id %q", id)
+	}
+
+	return row, letter, nil
+}
+
+// IsBlocked reports whether the seat isn't sold.
+func (l Layout) IsBlocked(row int, letter byte) bool {
+	return slices.Contains(l.Blocked, SeatID(row, letter))
+}
+
+// HasSeat reports whether the layout has the seat with the ID, blocked seats aren't seats.
+func (l Layout) HasSeat(id string) bool {
+	row, letter, err := ParseSeatID(id)
+	if err != nil {
+		return false
+	}
+
+	return row <= l.Rows && strings.IndexByte(l.Letters, letter) >= 0 && !l.IsBlocked(row, letter)
+}
+
+// HasAisleAfter reports whether an aisle follows the seat letter.
+func (l Layout) HasAisleAfter(letter byte) bool {
+	return strings.IndexByte(l.AisleAfter, letter) >= 0
+}
+
+// Seats returns the seat IDs of the layout row by row, from the left window to the right window,
+// the same order add_seats() inserts them in.
+func (l Layout) Seats() []string {
+	seats := make([]string, 0, l.Rows*len(l.Letters))
+	for row := 1; row <= l.Rows; row++ {
+		for i := 0; i < len(l.Letters); i++ {
+			if !l.IsBlocked(row, l.Letters[i]) {
+				seats = append(seats, SeatID(row, l.Letters[i]))
+			}
+		}
+	}
+
+	return seats
+}
+
+// Capacity returns the number of seats sold.
+func (l Layout) Capacity() int {
+	return len(l.Seats())
+}
+
+// String describes the layout, e.g. "narrow-body (30 rows, ABC-DEF, 180 seats)".
+func (l Layout) String() string {
+	var groups strings.Builder
+	for i := 0; i < len(l.Letters); i++ {
+		groups.WriteByte(l.Letters[i])
+		if l.HasAisleAfter(l.Letters[i]) && i < len(l.Letters)-1 {
+			groups.WriteByte('-')
+		}
+	}
+
+	return fmt.Sprintf("%s (%d rows, %s, %d seats)", l.Name, l.Rows, groups.String(), l.Capacity())
+}
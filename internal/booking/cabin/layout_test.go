@@ -0,0 +1,54 @@
+package cabin
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSeatID(t *testing.T) {
+	for _, tt := range []struct {
+		id      string
+		row     int
+		letter  byte
+		wantErr bool
+	}{
+		{id: "1A", row: 1, letter: 'A'},
+		{id: "30F", row: 30, letter: 'F'},
+		{id: "A", wantErr: true},
+		{id: "0A", wantErr: true},
+		{id: "12", wantErr: true},
+		{id: "XA", wantErr: true},
+	} {
+		row, letter, err := ParseSeatID(tt.id)
+		if (err != nil) != tt.wantErr || row != tt.row || letter != tt.letter {
+			t.Errorf("ParseSeatID(%q) = %d, %c, %v, want %d, %c, error %v", tt.id, row, letter, err, tt.row, tt.letter, tt.wantErr)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	regional := Layout{Name: "regional", Rows: 18, Letters: "ABCD", AisleAfter: "B", Blocked: []string{"1C", "1D"}}
+
+	seats := regional.Seats()
+	if !slices.Equal(seats[:4], []string{"1A", "1B", "2A", "2B"}) || seats[len(seats)-1] != "18D" {
+		t.Errorf("Seats() = %v, want the seats row by row without the blocked ones", seats)
+	}
+
+	if got := regional.Capacity(); got != 70 {
+		t.Errorf("Capacity() = %d, want 70", got)
+	}
+
+	for id, want := range map[string]bool{"1A": true, "1C": false, "18D": true, "19A": false, "2E": false} {
+		if got := regional.HasSeat(id); got != want {
+			t.Errorf("HasSeat(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	if got := regional.String(); got != "regional (18 rows, AB-CD, 70 seats)" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if got := NarrowBody.Capacity(); got != 180 {
+		t.Errorf("NarrowBody.Capacity() = %d, want 180", got)
+	}
+}
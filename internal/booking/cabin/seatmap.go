@@ -0,0 +1,235 @@
+package cabin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Seat is a seat of a trip, with the passenger it's assigned to if it's booked.
+type Seat struct {
+	ID            string `json:"seat_id"`
+	Row           int    `json:"row"`
+	Letter        string `json:"letter"`
+	PassengerID   int32  `json:"passenger_id,omitempty"`
+	PassengerName string `json:"passenger_name,omitempty"`
+}
+
+// IsBooked reports whether the seat is assigned to a passenger.
+func (s Seat) IsBooked() bool {
+	return s.PassengerID != 0
+}
+
+// SeatMap is the seats of a trip as stored in the reservation table, laid out over the cabin of the trip.
+// The grid covers the rows and letters of the layout and of every seat of the trip, so seats the layout
+// doesn't know about are rendered too.
+type SeatMap struct {
+	TripID int32  `json:"trip_id"`
+	Layout Layout `json:"layout"`
+	// Rows is the number of rows of the grid, numbered from 1.
+	Rows int `json:"rows"`
+	// Letters are the seat letters of the grid, the letters of the layout followed by any other letter of the trip's seats.
+	Letters string `json:"letters"`
+	// Seats are the seats of the trip, in the order they were inserted in.
+	Seats []Seat `json:"seats"`
+
+	// byID is the index of every seat in Seats, it's built on first use for the seat maps read back from JSON.
+	byID map[string]int
+}
+
+// NewSeatMap returns the seat map of the trip from its GetTripSeats rows.
+func NewSeatMap(tripID int32, layout Layout, rows []store.GetTripSeatsRow) (*SeatMap, error) {
+	m := &SeatMap{
+		TripID:  tripID,
+		Layout:  layout,
+		Rows:    layout.Rows,
+		Letters: layout.Letters,
+		Seats:   make([]Seat, 0, len(rows)),
+		byID:    make(map[string]int, len(rows)),
+	}
+
+	var extra []byte
+	for _, r := range rows {
+		row, letter, err := ParseSeatID(r.SeatID)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing seat of trip %d: %w", tripID, err)
+		}
+
+		m.Rows = max(m.Rows, row)
+		if strings.IndexByte(m.Letters, letter) < 0 && bytes.IndexByte(extra, letter) < 0 {
+			extra = append(extra, letter)
+		}
+
+		m.byID[r.SeatID] = len(m.Seats)
+		m.Seats = append(m.Seats, Seat{
+			ID:            r.SeatID,
+			Row:           row,
+			Letter:        string(letter),
+			PassengerID:   r.PassengerID,
+			PassengerName: r.PassengerName,
+		})
+	}
+
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	m.Letters += string(extra)
+
+	return m, nil
+}
+
+// Seat returns the seat in the row with the letter, false if the trip has no such seat.
+func (m *SeatMap) Seat(row int, letter byte) (Seat, bool) {
+	if m.byID == nil {
+		m.byID = make(map[string]int, len(m.Seats))
+		for i, seat := range m.Seats {
+			m.byID[seat.ID] = i
+		}
+	}
+
+	i, ok := m.byID[SeatID(row, letter)]
+	if !ok {
+		return Seat{}, false
+	}
+
+	return m.Seats[i], true
+}
+
+// Booked returns the number of seats assigned to a passenger.
+func (m *SeatMap) Booked() int {
+	booked := 0
+	for _, seat := range m.Seats {
+		if seat.IsBooked() {
+			booked++
+		}
+	}
+
+	return booked
+}
+
+// WriteText renders the seat map as plain text, with a line per seat letter and a column per row.
+// Booked seats are shown as "x", available seats as "." and missing seats are left blank.
+func (m *SeatMap) WriteText(w io.Writer) error {
+	return m.write(w, func(seat Seat) string {
+		if seat.IsBooked() {
+			return "  x"
+		}
+		return "  ."
+	})
+}
+
+// WriteTerminal renders the seat map like WriteText, with the booked seats in red and the available ones in green.
+func (m *SeatMap) WriteTerminal(w io.Writer) error {
+	return m.write(w, func(seat Seat) string {
+		if seat.IsBooked() {
+			return "  \x1b[31mx\x1b[0m"
+		}
+		return "  \x1b[32m.\x1b[0m"
+	})
+}
+
+func (m *SeatMap) write(w io.Writer, cell func(Seat) string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Trip %d, %s, %d of %d seats booked\n", m.TripID, m.Layout, m.Booked(), len(m.Seats))
+
+	b.WriteString("   ")
+	for row := 1; row <= m.Rows; row++ {
+		fmt.Fprintf(&b, "%3d", row)
+	}
+	b.WriteString("\n")
+
+	for i := 0; i < len(m.Letters); i++ {
+		letter := m.Letters[i]
+		fmt.Fprintf(&b, "  %c", letter)
+		for row := 1; row <= m.Rows; row++ {
+			if seat, ok := m.Seat(row, letter); ok {
+				b.WriteString(cell(seat))
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("\n")
+
+		// Leave an empty line for the aisle
+		if m.Layout.HasAisleAfter(letter) && i < len(m.Letters)-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the seat map as an indented JSON document, along with its number of booked seats.
+// The document can be read back into a SeatMap.
+func (m *SeatMap) WriteJSON(w io.Writer) error {
+	doc := struct {
+		*SeatMap
+		Booked int `json:"booked"`
+	}{m, m.Booked()}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteSVG renders the seat map as an SVG, with a column per row and a gap for every aisle like the plain text map.
+// Booked seats are filled, and every seat shows its passenger on hover. The seat map may have been read back from
+// a report, so everything taken from it is escaped.
+func (m *SeatMap) WriteSVG(w io.Writer) error {
+	const (
+		cell   = 16
+		margin = 24
+		aisle  = 10
+		top    = 22
+	)
+
+	// The vertical position of the seats of every letter, leaving a gap after every aisle
+	ys := make([]int, len(m.Letters))
+	y := top
+	for i := 0; i < len(m.Letters); i++ {
+		ys[i] = y
+		y += cell
+		if m.Layout.HasAisleAfter(m.Letters[i]) && i < len(m.Letters)-1 {
+			y += aisle
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="9">`+"\n",
+		2*margin+m.Rows*cell, y+10)
+
+	for row := 1; row <= m.Rows; row++ {
+		x := margin + (row-1)*cell
+		if row == 1 || row%5 == 0 {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n", x+cell/2, top-6, row)
+		}
+
+		for i := 0; i < len(m.Letters); i++ {
+			seat, ok := m.Seat(row, m.Letters[i])
+			if !ok {
+				continue
+			}
+
+			fill, occupant := "#e0e0e0", "available"
+			if seat.IsBooked() {
+				fill, occupant = "#4575b4", seat.PassengerName
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s" stroke="#ffffff"><title>%s: %s</title></rect>`+"\n",
+				x, ys[i], cell, cell, fill, html.EscapeString(seat.ID), html.EscapeString(occupant))
+		}
+	}
+
+	for i := 0; i < len(m.Letters); i++ {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", margin/2, ys[i]+cell/2+3, html.EscapeString(m.Letters[i:i+1]))
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,102 @@
+package cabin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+func TestSeatMap(t *testing.T) {
+	layout := Layout{Name: "tiny", Rows: 2, Letters: "ABCD", AisleAfter: "B", Blocked: []string{"1C"}}
+	rows := []store.GetTripSeatsRow{
+		{SeatID: "1A", PassengerID: 7, PassengerName: "Ada"},
+		{SeatID: "1B"},
+		{SeatID: "1D", PassengerID: 9, PassengerName: "Bob"},
+		{SeatID: "2A"},
+		{SeatID: "2B"},
+		{SeatID: "2C"},
+		{SeatID: "2D"},
+		{SeatID: "3E", PassengerID: 11, PassengerName: "Cy"},
+	}
+
+	m, err := NewSeatMap(4, layout, rows)
+	if err != nil {
+		t.Fatalf("NewSeatMap() error = %v", err)
+	}
+
+	if m.Rows != 3 || m.Letters != "ABCDE" || m.Booked() != 3 {
+		t.Errorf("NewSeatMap() = %d rows, letters %q, %d booked, want 3 rows, letters ABCDE, 3 booked", m.Rows, m.Letters, m.Booked())
+	}
+
+	if seat, ok := m.Seat(1, 'D'); !ok || seat.PassengerName != "Bob" {
+		t.Errorf("Seat(1, 'D') = %+v, %v, want the seat of Bob", seat, ok)
+	}
+
+	if _, ok := m.Seat(1, 'C'); ok {
+		t.Error("Seat(1, 'C') found a blocked seat")
+	}
+
+	var text strings.Builder
+	if err := m.WriteText(&text); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	want := "Trip 4, tiny (2 rows, AB-CD, 7 seats), 3 of 8 seats booked\n" +
+		"     1  2  3\n" +
+		"  A  x  .   \n" +
+		"  B  .  .   \n" +
+		"\n" +
+		"  C     .   \n" +
+		"  D  x  .   \n" +
+		"  E        x\n"
+	if text.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", text.String(), want)
+	}
+
+	var out strings.Builder
+	if err := m.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var doc struct {
+		TripID int32  `json:"trip_id"`
+		Booked int    `json:"booked"`
+		Seats  []Seat `json:"seats"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &doc); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+
+	if doc.TripID != 4 || doc.Booked != 3 || len(doc.Seats) != 8 || doc.Seats[7] != (Seat{ID: "3E", Row: 3, Letter: "E", PassengerID: 11, PassengerName: "Cy"}) {
+		t.Errorf("WriteJSON() = %+v, want the 8 seats of trip 4 with 3 booked", doc)
+	}
+
+	var read SeatMap
+	if err := json.Unmarshal([]byte(out.String()), &read); err != nil {
+		t.Fatalf("WriteJSON() wrote a seat map that can't be read back: %v", err)
+	}
+
+	if seat, ok := read.Seat(3, 'E'); !ok || seat.PassengerName != "Cy" || read.Letters != "ABCDE" {
+		t.Errorf("Seat(3, 'E') of the seat map read back = %+v, %v, want the seat of Cy", seat, ok)
+	}
+
+	if _, err := NewSeatMap(4, layout, []store.GetTripSeatsRow{{SeatID: "A1"}}); err == nil {
+		t.Error("NewSeatMap() with an invalid seat id succeeded")
+	}
+}
+
+func TestWriteSVGEscapes(t *testing.T) {
+	// A seat map read back from a report isn't validated
+	m := &SeatMap{Rows: 1, Letters: "<", Seats: []Seat{{ID: "1<", Row: 1, Letter: "<", PassengerID: 1, PassengerName: "&"}}}
+
+	var out strings.Builder
+	if err := m.WriteSVG(&out); err != nil {
+		t.Fatalf("WriteSVG() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "<title>1&lt;: &amp;</title>") || !strings.Contains(out.String(), ">&lt;</text>") {
+		t.Errorf("WriteSVG() =\n%s\nwant the seat id, letter and passenger escaped", out.String())
+	}
+}
@@ -0,0 +1,318 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// claimSeatInBookingMode claims a seat for the passenger on conn in the booking mode of the run.
+func claimSeatInBookingMode(ctx context.Context,
+	c *config.Config,
+	conn *pgx.Conn,
+	tripID int32,
+	passenger store.Passenger,
+	status *bookingStatus,
+) error {
+	switch bookingMode(c) {
+	case config.PipelinedBooking:
+		pipelinedClaim, _ := bookingseat.PipelinedClaimFor(c.LockStrategy)
+		return claimSeatPipelined(ctx, c, conn, pipelinedClaim, tripID, passenger, status)
+	case config.ServerSideBooking:
+		lockMode, _ := bookingseat.LockMode(c.LockStrategy)
+		return claimSeatServerSide(ctx, c, conn, lockMode, tripID, passenger, status)
+	default:
+		return claimSeatInTx(ctx, c, conn, tripID, passenger, status)
+	}
+}
+
+// claimSeatInTx claims a seat for the passenger in a transaction on conn, one statement at a time.
+// With savepoint retries configured, a failed claim is rolled back to a savepoint and retried in the same transaction.
+func claimSeatInTx(ctx context.Context,
+	config *config.Config,
+	conn *pgx.Conn,
+	tripID int32,
+	passenger store.Passenger,
+	status *bookingStatus,
+) error {
+	// lockedAt is the time the seat of the current claim was returned by the lock strategy
+	var lockedAt, claimedAt time.Time
+
+	err := pgtx.WithTx(ctx, conn, pgtx.Options{IsolationLevel: config.TxIsolation}, func(tx pgx.Tx) (err error) {
+		defer func() {
+			claimedAt = time.Now()
+			if err == nil {
+				status.phase = phaseCommit
+			}
+		}()
+
+		if config.SavepointRetries <= 0 {
+			return claimSeat(ctx, tx, config.LockStrategy, tripID, passenger, status, &lockedAt)
+		}
+
+		for claim := 0; ; claim++ {
+			// Claim the seat in a savepoint, so a failed claim doesn't abort the whole transaction
+			sp, err := tx.Begin(ctx)
+			if err != nil {
+				return fmt.Errorf("error creating savepoint: %w", err)
+			}
+
+			err = claimSeat(ctx, sp, config.LockStrategy, tripID, passenger, status, &lockedAt)
+			if err == nil {
+				if err := sp.Commit(ctx); err != nil {
+					return fmt.Errorf("error releasing savepoint: %w", err)
+				}
+
+				return nil
+			}
+
+			// There is no point in trying again once the trip is full, the transaction is rolled back by WithTx
+			if errors.Is(err, pgx.ErrNoRows) || claim >= config.SavepointRetries {
+				return err
+			}
+
+			if rErr := sp.Rollback(ctx); rErr != nil {
+				return fmt.Errorf("%w, error rolling back to savepoint: %w", err, rErr)
+			}
+
+			// The locks taken by the failed claim are released by the rollback to the savepoint
+			status.lockHold += sinceLocked(&lockedAt)
+			status.savepointRetries++
+		}
+	})
+	status.lockHold += sinceLocked(&lockedAt)
+	status.commitLatency = commitLatency(err, claimedAt)
+
+	return err
+}
+
+// claimSeatPipelined claims a seat for the passenger in a transaction that is sent to the server in a single round trip.
+// The server doesn't report when the seat is locked, so the whole round trip is counted as the time the seat is held.
+func claimSeatPipelined(ctx context.Context,
+	config *config.Config,
+	conn *pgx.Conn,
+	pipelinedClaim bookingseat.PipelinedClaim,
+	tripID int32,
+	passenger store.Passenger,
+	status *bookingStatus,
+) error {
+	var seat bookingseat.Seat
+
+	// The statements of the batch are sent together, so a failure can't be told apart from the claim
+	status.phase = phaseClaim
+	lockedAt := time.Now()
+	err := pgtx.WithPipelinedTx(ctx, conn, pgtx.Options{IsolationLevel: config.TxIsolation}, func(b *pgx.Batch) {
+		pipelinedClaim(b, tripID, passenger.Identifier, &seat)
+	})
+	status.lockHold += sinceLocked(&lockedAt)
+	if err != nil {
+		return fmt.Errorf("error claiming a seat for passenger %s: %w", passenger.Name, err)
+	}
+
+	status.seatId = seat.SeatID
+
+	return nil
+}
+
+// claimSeatServerSide claims a seat for the passenger with a single call to the book_seat function,
+// which looks up, locks and assigns the seat inside Postgres. The seat is held from the call until the end of the transaction.
+func claimSeatServerSide(ctx context.Context,
+	config *config.Config,
+	conn *pgx.Conn,
+	lockMode string,
+	tripID int32,
+	passenger store.Passenger,
+	status *bookingStatus,
+) error {
+	var lockedAt, claimedAt time.Time
+
+	err := pgtx.WithTx(ctx, conn, pgtx.Options{IsolationLevel: config.TxIsolation}, func(tx pgx.Tx) error {
+		defer func() { claimedAt = time.Now() }()
+
+		status.phase = phaseClaim
+		lockedAt = time.Now()
+		seat, err := bookingseat.BookSeatWithFunction(ctx, store.New(tx), tripID, passenger.Identifier, lockMode)
+		if err != nil {
+			return fmt.Errorf("error booking a seat with book_seat(%s) for passenger %s: %w", lockMode, passenger.Name, err)
+		}
+
+		status.seatId = seat.SeatID
+		status.phase = phaseCommit
+
+		return nil
+	})
+	status.lockHold += sinceLocked(&lockedAt)
+	status.commitLatency = commitLatency(err, claimedAt)
+
+	return err
+}
+
+// claimSeat looks up the next available seat with the lock strategy and assigns it to the passenger in tx.
+// The claimed seat and the phase the claim got to are recorded in status, and lockedAt is set to the time the seat
+// was returned by the lock strategy.
+func claimSeat(ctx context.Context,
+	tx pgx.Tx,
+	seatLockStrategy bookingseat.LockStrategy,
+	tripID int32,
+	passenger store.Passenger,
+	status *bookingStatus,
+	lockedAt *time.Time,
+) error {
+	// Get queries instance to execute requests in the transaction
+	q := store.New(tx)
+
+	// Get the next available seat
+	status.phase = phaseLock
+	seat, err := seatLockStrategy(ctx, q, tripID)
+	if err != nil {
+		return fmt.Errorf("error getting next available seat for passenger %s: %w", passenger.Name, err)
+	}
+
+	*lockedAt = time.Now()
+	status.seatId = seat.SeatID
+
+	// Book a seat for the passenger
+	status.phase = phaseUpdate
+	_, err = q.BookSeat(ctx, store.BookSeatParams{PassengerID: passenger.Identifier, Identifier: seat.ID})
+	if err != nil {
+		return fmt.Errorf("error booking seat %s for passenger %s: %w", seat.SeatID, passenger.Name, err)
+	}
+
+	return nil
+}
+
+// commitLatency returns the time since the claim was done, i.e. the time WithTx took to commit it, or 0 if it wasn't committed.
+func commitLatency(err error, claimedAt time.Time) time.Duration {
+	if err != nil || claimedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(claimedAt)
+}
+
+// sinceLocked returns the time elapsed since lockedAt and resets it, it returns 0 if no seat is held.
+func sinceLocked(lockedAt *time.Time) time.Duration {
+	if lockedAt.IsZero() {
+		return 0
+	}
+
+	held := time.Since(*lockedAt)
+	*lockedAt = time.Time{}
+
+	return held
+}
+
+// claimStats aggregates the retries and seat hold times of the attempts of a run.
+type claimStats struct {
+	passengers       int
+	attempts         int
+	savepointRetries int
+	roundTrips       int64
+	bookings         int
+	lockHolds        int
+	totalLockHold    time.Duration
+	maxLockHold      time.Duration
+}
+
+func (c *claimStats) add(status bookingStatus) {
+	c.attempts++
+	c.savepointRetries += status.savepointRetries
+	c.roundTrips += status.roundTrips
+	if status.err == nil {
+		c.bookings++
+	}
+
+	if status.lockHold > 0 {
+		c.lockHolds++
+		c.totalLockHold += status.lockHold
+		c.maxLockHold = max(c.maxLockHold, status.lockHold)
+	}
+}
+
+// transactionRetries returns the number of attempts made beyond the first attempt of every passenger.
+func (c *claimStats) transactionRetries() int {
+	return max(c.attempts-c.passengers, 0)
+}
+
+func (c *claimStats) meanLockHold() time.Duration {
+	if c.lockHolds == 0 {
+		return 0
+	}
+
+	return c.totalLockHold / time.Duration(c.lockHolds)
+}
+
+// roundTripsPerAttempt and roundTripsPerBooking return the mean number of round trips to the server made by an attempt,
+// and spent on every successful booking including its failed attempts.
+func (c *claimStats) roundTripsPerAttempt() float64 {
+	if c.attempts == 0 {
+		return 0
+	}
+
+	return float64(c.roundTrips) / float64(c.attempts)
+}
+
+func (c *claimStats) roundTripsPerBooking() float64 {
+	if c.bookings == 0 {
+		return 0
+	}
+
+	return float64(c.roundTrips) / float64(c.bookings)
+}
+
+// bookingMode returns the booking mode used for the run, falling back to client-side booking
+// if the lock strategy has no counterpart for the configured mode.
+func bookingMode(c *config.Config) config.BookingMode {
+	switch c.BookingMode {
+	case config.PipelinedBooking:
+		if _, ok := bookingseat.PipelinedClaimFor(c.LockStrategy); ok {
+			return config.PipelinedBooking
+		}
+	case config.ServerSideBooking:
+		if _, ok := bookingseat.LockMode(c.LockStrategy); ok {
+			return config.ServerSideBooking
+		}
+	}
+
+	return config.ClientSideBooking
+}
+
+func claimMode(c *config.Config) string {
+	switch bookingMode(c) {
+	case config.PipelinedBooking:
+		return "pipelined"
+	case config.ServerSideBooking:
+		return "server-side"
+	}
+
+	if c.SavepointRetries > 0 {
+		return fmt.Sprintf("savepoint (up to %d retries per transaction)", c.SavepointRetries)
+	}
+
+	return "transaction"
+}
+
+func printClaimStats(config *config.Config, c claimStats) {
+	logrus.Infof("Claim mode: %s, isolation level: %s, transaction retries: %d, savepoint retries: %d, seat hold time: mean %v, max %v",
+		claimMode(config),
+		config.TxIsolation,
+		c.transactionRetries(),
+		c.savepointRetries,
+		c.meanLockHold(),
+		c.maxLockHold,
+	)
+
+	logrus.Infof("Round trips: %d, %.2f per attempt, %.2f per booking",
+		c.roundTrips,
+		c.roundTripsPerAttempt(),
+		c.roundTripsPerBooking(),
+	)
+}
@@ -0,0 +1,199 @@
+package booking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errorPhase is the phase of a booking attempt, an attempt that fails records the phase it failed in.
+type errorPhase string
+
+const (
+	// phaseAcquire is waiting for a pool connection.
+	phaseAcquire errorPhase = "acquire"
+	// phaseBegin is starting the transaction.
+	phaseBegin errorPhase = "begin"
+	// phaseLock is looking up and locking the next available seat with the lock strategy.
+	phaseLock errorPhase = "lock"
+	// phaseUpdate is assigning the locked seat to the passenger.
+	phaseUpdate errorPhase = "update"
+	// phaseClaim is looking up, locking and assigning the seat in a single statement or batch,
+	// as pipelined and server-side bookings do.
+	phaseClaim errorPhase = "claim"
+	// phaseCommit is committing the transaction.
+	phaseCommit errorPhase = "commit"
+)
+
+// sqlStateNames are the condition names of the SQLSTATEs the bookings are expected to fail with.
+var sqlStateNames = map[string]string{
+	"40001": "serialization_failure",
+	"40P01": "deadlock_detected",
+	"55P03": "lock_not_available",
+	"57014": "query_canceled",
+	"25P02": "in_failed_sql_transaction",
+	"23505": "unique_violation",
+	"53300": "too_many_connections",
+}
+
+// errorCause is what a failed attempt failed with, and where.
+type errorCause struct {
+	sqlState string
+	phase    errorPhase
+}
+
+func (c errorCause) String() string {
+	if name, ok := sqlStateNames[c.sqlState]; ok {
+		return c.sqlState + " " + name
+	}
+
+	return c.sqlState
+}
+
+// errorTaxonomy aggregates the failed attempts of a run by SQLSTATE, phase and attempt number,
+// along with how many failures the passengers went through before they were booked or gave up.
+type errorTaxonomy struct {
+	// failures counts the failed attempts by cause and attempt number.
+	failures map[errorCause]map[int]int
+	// maxAttempt is the highest attempt number seen.
+	maxAttempt int
+	// bookedAfter counts the booked passengers by the number of failed attempts before their booking.
+	bookedAfter map[int]int
+	// gaveUpAfter counts the passengers that weren't booked by the number of attempts they made.
+	gaveUpAfter map[int]int
+}
+
+func newErrorTaxonomy() errorTaxonomy {
+	return errorTaxonomy{
+		failures:    make(map[errorCause]map[int]int),
+		bookedAfter: make(map[int]int),
+		gaveUpAfter: make(map[int]int),
+	}
+}
+
+// add records the attempt if it failed.
+func (e *errorTaxonomy) add(status bookingStatus) {
+	e.maxAttempt = max(e.maxAttempt, status.attempt)
+	if status.err == nil {
+		return
+	}
+
+	cause := errorCause{sqlState: sqlState(status.err), phase: status.phase}
+	if e.failures[cause] == nil {
+		e.failures[cause] = make(map[int]int)
+	}
+	e.failures[cause][status.attempt]++
+}
+
+// addOutcomes records whether every passenger was booked, given the last attempt of every passenger.
+func (e *errorTaxonomy) addOutcomes(outcomes map[int32]bookingStatus) {
+	for _, last := range outcomes {
+		if last.err == nil {
+			e.bookedAfter[last.attempt-1]++
+		} else {
+			e.gaveUpAfter[last.attempt]++
+		}
+	}
+}
+
+// failedAttempts returns the number of failed attempts.
+func (e *errorTaxonomy) failedAttempts() int {
+	total := 0
+	for _, byAttempt := range e.failures {
+		for _, n := range byAttempt {
+			total += n
+		}
+	}
+
+	return total
+}
+
+// causes returns the causes of the failures, the most frequent first.
+func (e *errorTaxonomy) causes() []errorCause {
+	totals := make(map[errorCause]int, len(e.failures))
+	causes := make([]errorCause, 0, len(e.failures))
+	for cause, byAttempt := range e.failures {
+		for _, n := range byAttempt {
+			totals[cause] += n
+		}
+		causes = append(causes, cause)
+	}
+
+	sort.Slice(causes, func(i, j int) bool {
+		if totals[causes[i]] != totals[causes[j]] {
+			return totals[causes[i]] > totals[causes[j]]
+		}
+		if causes[i].sqlState != causes[j].sqlState {
+			return causes[i].sqlState < causes[j].sqlState
+		}
+		return causes[i].phase < causes[j].phase
+	})
+
+	return causes
+}
+
+// outcomeSummary describes how many failures the passengers went through, e.g.
+// "170 booked on the first attempt, 8 after 1 failure, 2 gave up after 3 attempts".
+func (e *errorTaxonomy) outcomeSummary() string {
+	var parts []string
+	for _, failures := range sortedKeys(e.bookedAfter) {
+		n := e.bookedAfter[failures]
+		switch failures {
+		case 0:
+			parts = append(parts, fmt.Sprintf("%d booked on the first attempt", n))
+		case 1:
+			parts = append(parts, fmt.Sprintf("%d after 1 failure", n))
+		default:
+			parts = append(parts, fmt.Sprintf("%d after %d failures", n, failures))
+		}
+	}
+
+	for _, attempts := range sortedKeys(e.gaveUpAfter) {
+		n := e.gaveUpAfter[attempts]
+		if attempts == 1 {
+			parts = append(parts, fmt.Sprintf("%d gave up after 1 attempt", n))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d gave up after %d attempts", n, attempts))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
+
+func printErrorTaxonomy(e errorTaxonomy) {
+	logrus.Infof("Errors: %d failed attempts, passengers: %s", e.failedAttempts(), e.outcomeSummary())
+
+	causes := e.causes()
+	if len(causes) == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("%-32s %-8s", "SQLSTATE", "phase")
+	for attempt := 1; attempt <= e.maxAttempt; attempt++ {
+		header += fmt.Sprintf(" %10s", fmt.Sprintf("attempt %d", attempt))
+	}
+	logrus.Infof("%s %7s", header, "total")
+
+	for _, cause := range causes {
+		row := fmt.Sprintf("%-32s %-8s", cause, cause.phase)
+		total := 0
+		for attempt := 1; attempt <= e.maxAttempt; attempt++ {
+			n := e.failures[cause][attempt]
+			total += n
+			row += fmt.Sprintf(" %10d", n)
+		}
+		logrus.Infof("%s %7d", row, total)
+	}
+}
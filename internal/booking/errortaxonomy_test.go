@@ -0,0 +1,53 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	pgconn2 "github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	deadlock := fmt.Errorf("retry 1/3 failed: %w", &pgconn2.PgError{Code: "40P01"})
+	statuses := []bookingStatus{
+		{passengerID: 1, attempt: 1},
+		{passengerID: 2, attempt: 1, phase: phaseLock, err: deadlock},
+		{passengerID: 2, attempt: 2},
+		{passengerID: 3, attempt: 1, phase: phaseLock, err: deadlock},
+		{passengerID: 3, attempt: 2, phase: phaseCommit, err: &pgconn2.PgError{Code: "40001"}},
+		{passengerID: 3, attempt: 3, phase: phaseLock, err: fmt.Errorf("no seat left: %w", pgx.ErrNoRows)},
+		{passengerID: 4, attempt: 1, phase: phaseAcquire, err: context.DeadlineExceeded},
+	}
+
+	taxonomy := newErrorTaxonomy()
+	outcomes := make(map[int32]bookingStatus)
+	for _, status := range statuses {
+		taxonomy.add(status)
+		outcomes[status.passengerID] = status
+	}
+	taxonomy.addOutcomes(outcomes)
+
+	if got := taxonomy.failedAttempts(); got != 5 {
+		t.Errorf("failedAttempts() = %d, want 5", got)
+	}
+
+	causes := taxonomy.causes()
+	if len(causes) != 4 || causes[0] != (errorCause{sqlState: "40P01", phase: phaseLock}) {
+		t.Fatalf("causes() = %v, want 4 causes with the deadlocks first", causes)
+	}
+
+	if got := causes[0].String(); got != "40P01 deadlock_detected" {
+		t.Errorf("String() = %q, want the SQLSTATE with its condition name", got)
+	}
+
+	if got := taxonomy.failures[errorCause{sqlState: "no_rows", phase: phaseLock}][3]; got != 1 {
+		t.Errorf("no_rows failures of attempt 3 = %d, want 1", got)
+	}
+
+	want := "1 booked on the first attempt, 1 after 1 failure, 1 gave up after 1 attempt, 1 gave up after 3 attempts"
+	if got := taxonomy.outcomeSummary(); got != want {
+		t.Errorf("outcomeSummary() = %q, want %q", got, want)
+	}
+}
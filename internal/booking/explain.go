@@ -0,0 +1,196 @@
+package booking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
+	pgexplain "github.com/soumya-codes/airline-reservation-poc/internal/postgres/explain"
+	pgstatements "github.com/soumya-codes/airline-reservation-poc/internal/postgres/statements"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// partialIndex is the index the lock strategies are expected to look up the available seats of a trip with.
+const partialIndex = "idx_reservation_trip_id_passenger_id_seat_id"
+
+// strategyPlans are the plans of the queries run by a lock strategy.
+type strategyPlans struct {
+	Strategy string           `json:"strategy"`
+	TripID   int32            `json:"trip_id"`
+	Plans    []pgexplain.Plan `json:"plans"`
+}
+
+// ExplainStrategies explains the queries of every lock strategy against the next available trip, without booking it,
+// and writes the plans to dir as explain-trip-<trip id>.json and .txt. The queries are rolled back after they are explained.
+func ExplainStrategies(ctx context.Context, c *config.Config, dir string) error {
+	conn, err := pgconn.NewConnection(c.PostgresConfig)
+	if err != nil {
+		return fmt.Errorf("error connecting to database to explain the lock strategies: %w", err)
+	}
+
+	defer func() {
+		if err := pgconn.Close(conn); err != nil {
+			logrus.WithError(err).Error("error closing database connection")
+		}
+	}()
+
+	q := store.New(conn)
+	passengers, err := GetPassengers(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	if len(passengers) == 0 {
+		return errors.New("error explaining the lock strategies: no passengers")
+	}
+
+	tripID, err := GetNextAvailableTrip(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	explained := make([]strategyPlans, 0)
+	for _, strategy := range bookingseat.Strategies() {
+		plans, err := explainStrategy(ctx, conn, strategy, tripID, passengers[0].Identifier)
+		if err != nil {
+			return err
+		}
+
+		explained = append(explained, plans)
+		printPlans(plans)
+	}
+
+	return writePlans(dir, fmt.Sprintf("explain-trip-%d", tripID), explained)
+}
+
+// explainStrategy explains the queries of the lock strategy for booking a seat of the trip for the passenger:
+// its lookup query and the seat assignment of a client-side booking, and its claim query of a pipelined booking.
+func explainStrategy(ctx context.Context, conn *pgx.Conn, strategy bookingseat.LockStrategy, tripID int32, passengerID int32) (strategyPlans, error) {
+	name := bookingseat.Name(strategy)
+	queries, ok := bookingseat.QueriesFor(strategy)
+	if !ok {
+		return strategyPlans{}, fmt.Errorf("error explaining lock strategy %s: its queries are unknown", name)
+	}
+
+	explained := strategyPlans{Strategy: name, TripID: tripID}
+	explain := func(query string, args ...any) error {
+		plan, err := pgexplain.Explain(ctx, conn, pgstatements.QueryName(query), query, args...)
+		if err != nil {
+			return err
+		}
+
+		explained.Plans = append(explained.Plans, plan)
+		return nil
+	}
+
+	if err := explain(queries.Lookup, tripID); err != nil {
+		return strategyPlans{}, err
+	}
+
+	// Assign the seat the lookup would return, the trip may be full already
+	seat, err := store.New(conn).GetSeatWithNoLock(ctx, tripID)
+	switch {
+	case err == nil:
+		if err := explain(store.BookSeat, passengerID, seat.Identifier); err != nil {
+			return strategyPlans{}, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return strategyPlans{}, fmt.Errorf("error getting a seat to explain %s: %w", store.BookSeat, err)
+	}
+
+	if err := explain(queries.Claim, tripID, passengerID); err != nil {
+		return strategyPlans{}, err
+	}
+
+	return explained, nil
+}
+
+// explainRun explains the queries of the run's lock strategy against the trip about to be booked,
+// and writes the plans to the explain directory as explain-<run id>.json and .txt.
+func explainRun(ctx context.Context, c *config.Config, conn *pgx.Conn, runID string, tripID int32, passengers []store.Passenger) []strategyPlans {
+	if c.ExplainDir == "" || len(passengers) == 0 {
+		return nil
+	}
+
+	plans, err := explainStrategy(ctx, conn, c.LockStrategy, tripID, passengers[0].Identifier)
+	if err != nil {
+		logrus.WithError(err).Warn("error explaining the queries of the lock strategy")
+		return nil
+	}
+
+	explained := []strategyPlans{plans}
+	if err := writePlans(c.ExplainDir, "explain-"+runID, explained); err != nil {
+		logrus.WithError(err).Error("error writing query plans")
+	}
+
+	return explained
+}
+
+// writePlans writes the plans as JSON, and their shapes as text, so plans of different runs can be diffed.
+func writePlans(dir string, name string, explained []strategyPlans) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating explain directory: %w", err)
+	}
+
+	base := filepath.Join(dir, name)
+	err := writeFile(base+".json", func(f *os.File) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(explained)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(base+".txt", func(f *os.File) error {
+		for _, plans := range explained {
+			if err := writePlanShapes(f, plans); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func writePlanShapes(w io.Writer, plans strategyPlans) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", plans.Strategy)
+	for _, plan := range plans.Plans {
+		fmt.Fprintf(&b, "## %s (%s: %s)\n%s\n", plan.Name, partialIndex, partialIndexUse(plan), plan.Shape())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func partialIndexUse(plan pgexplain.Plan) string {
+	if plan.UsesIndex(partialIndex) {
+		return "used"
+	}
+
+	return "not used"
+}
+
+func printPlans(plans strategyPlans) {
+	for _, plan := range plans.Plans {
+		logrus.Infof("Plan of %s for %s: %s %s, execution time: %.3fms, indexes: %v",
+			plan.Name,
+			plans.Strategy,
+			partialIndex,
+			partialIndexUse(plan),
+			plan.ExecutionTime,
+			plan.Indexes(),
+		)
+	}
+}
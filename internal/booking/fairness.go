@@ -0,0 +1,76 @@
+package booking
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
+)
+
+// Fairness compares the order in which acquire requests arrived at the pool with the order in which they were handed a connection.
+type Fairness struct {
+	Acquires int
+	// Inversions is the number of acquire pairs that were handed their connections in the opposite order of their arrival.
+	Inversions int
+	// KendallTau is the normalised Kendall tau distance, 0 when connections were handed over strictly in arrival order
+	// and 1 when they were handed over in reverse arrival order.
+	KendallTau float64
+	// MeanDisplacement and MaxDisplacement measure how many positions an acquire moved between the two orders.
+	MeanDisplacement float64
+	MaxDisplacement  int
+}
+
+// measureFairness computes the fairness of a run from the arrival positions of the acquire requests at the pool,
+// listed in the order in which they were handed a connection, see pgpool.ConnectionPool.AcquisitionOrder.
+func measureFairness(acquisitionOrder []int64) Fairness {
+	n := len(acquisitionOrder)
+	f := Fairness{Acquires: n}
+	if n < 2 {
+		return f
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if acquisitionOrder[i] > acquisitionOrder[j] {
+				f.Inversions++
+			}
+		}
+	}
+	f.KendallTau = float64(f.Inversions) / float64(n*(n-1)/2)
+
+	// Arrival positions are not contiguous when some acquires were cancelled, so rank them first.
+	arrivalOrder := make([]int64, n)
+	copy(arrivalOrder, acquisitionOrder)
+	sort.Slice(arrivalOrder, func(i, j int) bool { return arrivalOrder[i] < arrivalOrder[j] })
+	arrivalRank := make(map[int64]int, n)
+	for rank, arrival := range arrivalOrder {
+		arrivalRank[arrival] = rank
+	}
+
+	total := 0
+	for position, arrival := range acquisitionOrder {
+		displacement := position - arrivalRank[arrival]
+		if displacement < 0 {
+			displacement = -displacement
+		}
+
+		total += displacement
+		if displacement > f.MaxDisplacement {
+			f.MaxDisplacement = displacement
+		}
+	}
+	f.MeanDisplacement = float64(total) / float64(n)
+
+	return f
+}
+
+func printFairness(poolPolicy pgpool.AcquisitionPolicy, f Fairness) {
+	logrus.Infof("Pool acquisition policy: %s, fairness over %d acquires: kendall-tau distance %.3f (%d inversions), mean displacement %.2f, max displacement %d",
+		poolPolicy,
+		f.Acquires,
+		f.KendallTau,
+		f.Inversions,
+		f.MeanDisplacement,
+		f.MaxDisplacement,
+	)
+}
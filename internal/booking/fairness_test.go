@@ -0,0 +1,40 @@
+package booking
+
+import "testing"
+
+func TestMeasureFairness(t *testing.T) {
+	tests := []struct {
+		name             string
+		acquisitionOrder []int64
+		want             Fairness
+	}{
+		{
+			name:             "ArrivalOrder",
+			acquisitionOrder: []int64{1, 2, 3, 4},
+			want:             Fairness{Acquires: 4},
+		},
+		{
+			name:             "ReverseOrder",
+			acquisitionOrder: []int64{4, 3, 2, 1},
+			want:             Fairness{Acquires: 4, Inversions: 6, KendallTau: 1, MeanDisplacement: 2, MaxDisplacement: 3},
+		},
+		{
+			name:             "CancelledAcquiresLeaveGaps",
+			acquisitionOrder: []int64{2, 7, 5},
+			want:             Fairness{Acquires: 3, Inversions: 1, KendallTau: 1.0 / 3, MeanDisplacement: 2.0 / 3, MaxDisplacement: 1},
+		},
+		{
+			name:             "SingleBooking",
+			acquisitionOrder: []int64{1},
+			want:             Fairness{Acquires: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := measureFairness(tt.acquisitionOrder); got != tt.want {
+				t.Errorf("measureFairness(%v) = %+v, want %+v", tt.acquisitionOrder, got, tt.want)
+			}
+		})
+	}
+}
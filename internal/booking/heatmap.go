@@ -0,0 +1,249 @@
+package booking
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+)
+
+// seatHeat is how often a seat was returned by the lock strategy during a run.
+type seatHeat struct {
+	// claims is the number of attempts the seat was returned to.
+	claims int
+	// failures is the number of those attempts that failed after the seat was returned to them.
+	failures int
+	// bookings is the number of those attempts that committed, more than 1 is a double booking.
+	bookings int
+}
+
+// seatContention is the heat of every seat returned by the lock strategy during a run, by seat ID.
+// Pipelined and server-side bookings only report the seat they claimed when they succeed, so their failed claims aren't counted.
+type seatContention map[string]*seatHeat
+
+// add records the seat the attempt was returned, if any.
+func (c seatContention) add(status bookingStatus) {
+	if status.seatId == "" {
+		return
+	}
+
+	heat, ok := c[status.seatId]
+	if !ok {
+		heat = &seatHeat{}
+		c[status.seatId] = heat
+	}
+
+	heat.claims++
+	if status.err != nil {
+		heat.failures++
+	} else {
+		heat.bookings++
+	}
+}
+
+// hottest returns the seat returned to the most attempts, ties are broken by seat ID.
+func (c seatContention) hottest() (string, *seatHeat) {
+	ids := make([]string, 0, len(c))
+	for id := range c {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var hottestID string
+	var hottest *seatHeat
+	for _, id := range ids {
+		if hottest == nil || c[id].claims > hottest.claims {
+			hottestID, hottest = id, c[id]
+		}
+	}
+
+	return hottestID, hottest
+}
+
+// totals returns the number of claims, failed claims and double-booked seats of the run.
+func (c seatContention) totals() (claims int, failures int, doubleBooked int) {
+	for _, heat := range c {
+		claims += heat.claims
+		failures += heat.failures
+		if heat.bookings > 1 {
+			doubleBooked++
+		}
+	}
+
+	return claims, failures, doubleBooked
+}
+
+// heat returns the heat of the seat in the row with the letter, the zero heat if it was never returned.
+func (c seatContention) heat(row int, letter byte) seatHeat {
+	if heat, ok := c[cabin.SeatID(row, letter)]; ok {
+		return *heat
+	}
+
+	return seatHeat{}
+}
+
+// heatLevels are the lower bounds of the claims of a seat in each colour band of the heatmap.
+var heatLevels = []int{1, 2, 4, 8, 16}
+
+// heatLevel returns the colour band of the seat, 0 if it was never returned.
+func heatLevel(claims int) int {
+	level := 0
+	for i, lower := range heatLevels {
+		if claims >= lower {
+			level = i + 1
+		}
+	}
+
+	return level
+}
+
+// ansiHeatColours are the 256-colour terminal backgrounds of the heat levels, from green to red.
+var ansiHeatColours = []int{0, 28, 142, 214, 202, 160}
+
+// svgHeatColours are the fills of the heat levels in the SVG heatmap.
+var svgHeatColours = []string{"#eeeeee", "#1a9850", "#a6d96a", "#fee08b", "#f46d43", "#a50026"}
+
+// printHeatmap prints the number of attempts every seat was returned to over the cabin, coloured by heat level.
+func printHeatmap(layout cabin.Layout, c seatContention, file string) {
+	claims, failures, doubleBooked := c.totals()
+	if claims == 0 {
+		return
+	}
+
+	hottestID, hottest := c.hottest()
+	logrus.Infof("Seat contention: %d claims of %d seats, %d failed, %d seats double-booked, most contended seat %s (%d claims, %d failed)",
+		claims, len(c), failures, doubleBooked, hottestID, hottest.claims, hottest.failures)
+	if file != "" {
+		logrus.Infof("Seat contention heatmap written to %s", file)
+	}
+
+	var b strings.Builder
+	b.WriteString("     ")
+	for row := 1; row <= layout.Rows; row++ {
+		fmt.Fprintf(&b, "%3d", row)
+	}
+	b.WriteString("\n")
+
+	for i := 0; i < len(layout.Letters); i++ {
+		letter := layout.Letters[i]
+		fmt.Fprintf(&b, "  %c  ", letter)
+		for row := 1; row <= layout.Rows; row++ {
+			heat := c.heat(row, letter)
+			switch {
+			case layout.IsBlocked(row, letter):
+				b.WriteString("   ")
+			case heat.claims == 0:
+				b.WriteString("  .")
+			default:
+				fmt.Fprintf(&b, "\x1b[48;5;%dm%3d\x1b[0m", ansiHeatColours[heatLevel(heat.claims)], heat.claims)
+			}
+		}
+		b.WriteString("\n")
+
+		// Leave a gap for the aisle
+		if layout.HasAisleAfter(letter) {
+			b.WriteString("\n")
+		}
+	}
+
+	fmt.Print(b.String())
+}
+
+// writeHeatmap writes the heatmap of the run as an SVG to heatmap-<run id>.svg, it returns the name of the file written.
+func writeHeatmap(c *config.Config, runID string, layout cabin.Layout, contention seatContention) (string, error) {
+	if c.HeatmapDir == "" {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(c.HeatmapDir, 0o755); err != nil {
+		return "", fmt.Errorf("error creating heatmap directory: %w", err)
+	}
+
+	name := filepath.Join(c.HeatmapDir, "heatmap-"+runID+".svg")
+	title := fmt.Sprintf("Seat contention of run %s, %s, %s", runID, c.TxIsolation, bookingseat.Name(c.LockStrategy))
+	if err := writeFile(name, func(f *os.File) error { return writeHeatmapSVG(f, layout, contention, title) }); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// writeHeatmapSVG renders the heatmap over the cabin, with a column per row of seats and a gap for every aisle.
+// Every seat shows the number of attempts it was returned to, and its tooltip the failed and committed ones.
+func writeHeatmapSVG(w io.Writer, layout cabin.Layout, c seatContention, title string) error {
+	const (
+		cell   = 26
+		margin = 30
+		aisle  = 14
+		top    = 50
+	)
+
+	// The vertical position of the seats of every letter, leaving a gap after every aisle
+	ys := make([]int, len(layout.Letters))
+	y := top
+	for i := 0; i < len(layout.Letters); i++ {
+		ys[i] = y
+		y += cell
+		if layout.HasAisleAfter(layout.Letters[i]) {
+			y += aisle
+		}
+	}
+
+	width := 2*margin + layout.Rows*cell
+	legendY := y + 20
+	height := legendY + 40
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="10">`+"\n", width, height)
+	fmt.Fprintf(&b, `<text x="%d" y="20" font-size="14">%s</text>`+"\n", margin, escapeXML(title))
+
+	for row := 1; row <= layout.Rows; row++ {
+		x := margin + (row-1)*cell
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n", x+cell/2, top-6, row)
+
+		for i := 0; i < len(layout.Letters); i++ {
+			letter := layout.Letters[i]
+			if layout.IsBlocked(row, letter) {
+				continue
+			}
+
+			heat := c.heat(row, letter)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#ffffff">`,
+				x, ys[i], cell, cell, svgHeatColours[heatLevel(heat.claims)])
+			fmt.Fprintf(&b, `<title>%s: %d claims, %d failed, %d booked</title></rect>`+"\n",
+				cabin.SeatID(row, letter), heat.claims, heat.failures, heat.bookings)
+
+			if heat.claims > 0 {
+				fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%d</text>`+"\n", x+cell/2, ys[i]+cell/2+4, heat.claims)
+			}
+		}
+	}
+
+	for i := 0; i < len(layout.Letters); i++ {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%c</text>`+"\n", margin/2, ys[i]+cell/2+4, layout.Letters[i])
+	}
+
+	// Legend of the heat levels
+	fmt.Fprintf(&b, `<text x="%d" y="%d">claims:</text>`+"\n", margin, legendY+12)
+	for i, lower := range heatLevels {
+		x := margin + 50 + i*60
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="16" height="16" fill="%s"/>`, x, legendY, svgHeatColours[i+1])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%d+</text>`+"\n", x+20, legendY+12, lower)
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
@@ -0,0 +1,51 @@
+package booking
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+)
+
+func TestSeatContention(t *testing.T) {
+	contention := make(seatContention)
+	for _, status := range []bookingStatus{
+		{booking: booking{seatId: "1A"}},
+		{booking: booking{seatId: "1A"}, err: errors.New("deadlock detected")},
+		{booking: booking{seatId: "1A"}},
+		{booking: booking{seatId: "2C"}},
+		{err: errors.New("no seat returned")},
+	} {
+		contention.add(status)
+	}
+
+	claims, failures, doubleBooked := contention.totals()
+	if claims != 4 || failures != 1 || doubleBooked != 1 {
+		t.Errorf("totals() = %d, %d, %d, want 4 claims, 1 failure, 1 double-booked seat", claims, failures, doubleBooked)
+	}
+
+	if id, heat := contention.hottest(); id != "1A" || heat.claims != 3 {
+		t.Errorf("hottest() = %s, %+v, want 1A with 3 claims", id, heat)
+	}
+
+	if heat := contention.heat(30, 'F'); heat != (seatHeat{}) {
+		t.Errorf("heat(30, F) = %+v, want the zero heat", heat)
+	}
+
+	var svg strings.Builder
+	if err := writeHeatmapSVG(&svg, cabin.NarrowBody, contention, "run <1>"); err != nil {
+		t.Fatalf("writeHeatmapSVG() error = %v", err)
+	}
+
+	got := svg.String()
+	for _, want := range []string{"<title>1A: 3 claims, 1 failed, 2 booked</title>", "run &lt;1&gt;", svgHeatColours[heatLevel(3)]} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeHeatmapSVG() doesn't contain %q", want)
+		}
+	}
+
+	if n := strings.Count(got, "<title>"); n != cabin.NarrowBody.Capacity() {
+		t.Errorf("writeHeatmapSVG() rendered %d seats, want %d", n, cabin.NarrowBody.Capacity())
+	}
+}
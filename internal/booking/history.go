@@ -0,0 +1,71 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/experiment"
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// experimentHistory collects the attempts of a run, to record them along with the run in the experiment history.
+// Its methods are no-ops on a nil history, so the bookings don't have to check whether the history is enabled.
+type experimentHistory struct {
+	runID    string
+	attempts []store.InsertExperimentAttemptsParams
+}
+
+// newExperimentHistory returns the history of the run, nil if the experiment history isn't enabled.
+func newExperimentHistory(c *config.Config, runID string) *experimentHistory {
+	if !c.ExperimentHistory {
+		return nil
+	}
+
+	return &experimentHistory{runID: runID}
+}
+
+func (h *experimentHistory) add(status bookingStatus) {
+	if h == nil {
+		return
+	}
+
+	attempt := store.InsertExperimentAttemptsParams{
+		RunID:         h.runID,
+		PassengerID:   status.passengerID,
+		Attempt:       int32(status.attempt),
+		Succeeded:     status.err == nil,
+		SeatID:        status.seatId,
+		AcquireWaitUs: status.acquireWait.Microseconds(),
+		AttemptUs:     status.attemptLatency.Microseconds(),
+		LockHoldUs:    status.lockHold.Microseconds(),
+		RoundTrips:    status.roundTrips,
+	}
+	if status.err != nil {
+		attempt.Sqlstate = sqlState(status.err)
+		attempt.Phase = string(status.phase)
+	}
+
+	h.attempts = append(h.attempts, attempt)
+}
+
+// record inserts the run, the server settings it was executed with and its attempts in the experiment_run and
+// experiment_attempt tables.
+func (h *experimentHistory) record(ctx context.Context, conn *pgx.Conn, run *report.Run) error {
+	if h == nil {
+		return nil
+	}
+
+	settings, err := experiment.ServerSettings(ctx, store.New(conn))
+	if err != nil {
+		return fmt.Errorf("error recording run in the experiment history: %w", err)
+	}
+
+	if err := experiment.Record(ctx, conn, experiment.RunParams(run, experiment.GitSHA(), settings), h.attempts); err != nil {
+		return fmt.Errorf("error recording run in the experiment history: %w", err)
+	}
+
+	return nil
+}
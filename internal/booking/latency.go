@@ -0,0 +1,66 @@
+package booking
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/internal/histogram"
+)
+
+// latencyStats are the histograms of the latencies of a run.
+type latencyStats struct {
+	// booking is the time from the booking request to the successful booking, including all failed attempts.
+	booking histogram.Histogram
+	// attempt is the time every attempt spent on its connection, successful or not.
+	attempt histogram.Histogram
+	// acquireWait is the time every attempt waited for a pool connection.
+	acquireWait histogram.Histogram
+	// commit is the time the commit of every committed attempt took, pipelined bookings commit in the same round trip
+	// as the claim, so they aren't counted.
+	commit histogram.Histogram
+}
+
+func (l *latencyStats) add(status bookingStatus) {
+	l.acquireWait.Record(status.acquireWait)
+	if status.attemptLatency > 0 {
+		l.attempt.Record(status.attemptLatency)
+	}
+
+	if status.commitLatency > 0 {
+		l.commit.Record(status.commitLatency)
+	}
+
+	if status.err == nil {
+		l.booking.Record(status.latency)
+	}
+}
+
+func printLatency(l latencyStats, elapsedTime time.Duration) {
+	throughput := 0.0
+	if elapsedTime > 0 {
+		throughput = float64(l.booking.Count()) / elapsedTime.Seconds()
+	}
+	logrus.Infof("Throughput: %d bookings in %v, %.1f bookings/s", l.booking.Count(), elapsedTime, throughput)
+
+	logrus.Infof("%-14s %7s %10s %10s %10s %10s %10s", "Latency", "count", "mean", "p50", "p90", "p99", "max")
+	for _, h := range []struct {
+		name      string
+		histogram *histogram.Histogram
+	}{
+		{name: "booking", histogram: &l.booking},
+		{name: "attempt", histogram: &l.attempt},
+		{name: "acquire wait", histogram: &l.acquireWait},
+		{name: "commit", histogram: &l.commit},
+	} {
+		s := h.histogram.Summary()
+		logrus.Infof("%-14s %7d %10v %10v %10v %10v %10v",
+			h.name,
+			s.Count,
+			s.Mean.Round(time.Microsecond),
+			s.P50,
+			s.P90,
+			s.P99,
+			s.Max.Round(time.Microsecond),
+		)
+	}
+}
@@ -0,0 +1,126 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
+	pglockgraph "github.com/soumya-codes/airline-reservation-poc/internal/postgres/lockgraph"
+)
+
+// attemptKey identifies a booking attempt of a passenger.
+type attemptKey struct {
+	passengerID int32
+	attempt     int
+}
+
+// lockGraphSummary describes the wait-for graph of a run and where it was written.
+type lockGraphSummary struct {
+	snapshots  int
+	backends   int
+	edges      int
+	maxWaiters int
+	files      []string
+}
+
+// startLockGraphCollector starts sampling the lock waits of the run on a connection of its own.
+// The returned stop func stops sampling and returns the snapshots taken, it returns no snapshots if the lock graph is disabled.
+func startLockGraphCollector(ctx context.Context, c *config.Config, runID string) (stop func() []pglockgraph.Snapshot, err error) {
+	if c.LockGraphInterval <= 0 {
+		return func() []pglockgraph.Snapshot { return nil }, nil
+	}
+
+	conn, err := pgconn.NewConnection(c.PostgresConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database to sample lock waits: %w", err)
+	}
+
+	collector := pglockgraph.NewCollector(conn, runID, c.LockGraphInterval)
+	collector.Start(ctx)
+
+	return func() []pglockgraph.Snapshot {
+		snapshots := collector.Stop()
+		if err := pgconn.Close(conn); err != nil {
+			logrus.WithError(err).Error("error closing lock graph connection")
+		}
+
+		return snapshots
+	}, nil
+}
+
+// writeLockGraph annotates the backends of the snapshots with the seats claimed by their booking attempts,
+// and writes the snapshots to lock-graph-<run id>.json and the aggregated wait-for graph to lock-graph-<run id>.dot.
+func writeLockGraph(c *config.Config, runID string, snapshots []pglockgraph.Snapshot, seats map[attemptKey]string) (lockGraphSummary, error) {
+	if c.LockGraphInterval <= 0 {
+		return lockGraphSummary{}, nil
+	}
+
+	pglockgraph.Annotate(snapshots, func(passengerID int32, attempt int) (string, bool) {
+		seat, ok := seats[attemptKey{passengerID: passengerID, attempt: attempt}]
+		return seat, ok
+	})
+
+	g := pglockgraph.Aggregate(snapshots)
+	summary := lockGraphSummary{snapshots: len(snapshots), backends: len(g.Nodes), edges: len(g.Edges)}
+	for _, snapshot := range snapshots {
+		waiters := make(map[int32]struct{})
+		for _, edge := range snapshot.Edges {
+			waiters[edge.Waiter] = struct{}{}
+		}
+
+		summary.maxWaiters = max(summary.maxWaiters, len(waiters))
+	}
+
+	if err := os.MkdirAll(c.LockGraphDir, 0o755); err != nil {
+		return summary, fmt.Errorf("error creating lock graph directory: %w", err)
+	}
+
+	base := filepath.Join(c.LockGraphDir, "lock-graph-"+runID)
+	if err := writeFile(base+".dot", func(f *os.File) error { return pglockgraph.WriteDOT(f, g) }); err != nil {
+		return summary, err
+	}
+	summary.files = append(summary.files, base+".dot")
+
+	if err := writeFile(base+".json", func(f *os.File) error { return pglockgraph.WriteJSON(f, snapshots) }); err != nil {
+		return summary, err
+	}
+	summary.files = append(summary.files, base+".json")
+
+	return summary, nil
+}
+
+func writeFile(name string, write func(f *os.File) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", name, err)
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func printLockGraph(summary lockGraphSummary) {
+	if len(summary.files) == 0 {
+		return
+	}
+
+	logrus.Infof("Lock wait-for graph: %d snapshots with lock waits, %d backends, %d edges, max %d backends waiting at once, written to %v",
+		summary.snapshots,
+		summary.backends,
+		summary.edges,
+		summary.maxWaiters,
+		summary.files,
+	)
+}
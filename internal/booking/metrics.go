@@ -0,0 +1,162 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	pgconn2 "github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	"github.com/soumya-codes/airline-reservation-poc/internal/metrics"
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
+)
+
+// bookingMetrics accumulates the metrics of every run of the process, so a scraper sees all the runs of an experiment.
+var bookingMetrics = metrics.NewRegistry()
+
+var (
+	metricsServersMu sync.Mutex
+	// metricsServers are the addresses /metrics is already served on.
+	metricsServers = make(map[string]bool)
+)
+
+// runMetrics records the metrics of a run, a nil runMetrics records nothing.
+type runMetrics struct {
+	labels metrics.Labels
+}
+
+// startRunMetrics serves /metrics on the configured address, unless it is already served, and exposes the pool's
+// connections as gauges for the duration of the run. The endpoint outlives the run, so that a scraper sees every run
+// of a long experiment. It returns nil if no metrics address is configured.
+func startRunMetrics(c *config.Config, pool *pgpool.ConnectionPool) (*runMetrics, error) {
+	if c.MetricsAddr == "" {
+		return nil, nil
+	}
+
+	if err := serveMetrics(c.MetricsAddr); err != nil {
+		return nil, err
+	}
+
+	m := newRunMetrics(c)
+	m.poolGauges(pool.Stats)
+
+	return m, nil
+}
+
+// newRunMetrics returns the metrics of a run, labelled with the isolation level, lock strategy and pool size of c.
+func newRunMetrics(c *config.Config) *runMetrics {
+	return &runMetrics{labels: metrics.Labels{
+		"isolation_level": string(c.TxIsolation),
+		"lock_strategy":   bookingseat.Name(c.LockStrategy),
+		"pool_size":       strconv.Itoa(c.MaxConn),
+	}}
+}
+
+// serveMetrics serves bookingMetrics at /metrics on addr, the listener is kept open for the lifetime of the process.
+func serveMetrics(addr string) error {
+	metricsServersMu.Lock()
+	defer metricsServersMu.Unlock()
+
+	if metricsServers[addr] {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening for metrics on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", bookingMetrics.Handler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			logrus.WithError(err).Errorf("error serving metrics on %s", addr)
+		}
+	}()
+
+	metricsServers[addr] = true
+	logrus.Infof("Serving metrics on http://%s/metrics", listener.Addr())
+
+	return nil
+}
+
+// poolGauges exposes the connections of the pool returned by stats as gauges.
+func (m *runMetrics) poolGauges(stats func() pgpool.Stats) {
+	bookingMetrics.GaugeFunc("booking_pool_connections", "Connections of the booking pool, by state.", m.with("state", "in_use"),
+		func() float64 { return float64(stats().InUse) })
+	bookingMetrics.GaugeFunc("booking_pool_connections", "Connections of the booking pool, by state.", m.with("state", "idle"),
+		func() float64 { return float64(stats().Idle) })
+	bookingMetrics.GaugeFunc("booking_pool_waiters", "Bookings waiting for a connection of the booking pool.", m.labels,
+		func() float64 { return float64(stats().Waiters) })
+}
+
+// record records the outcome and the latency of a booking attempt.
+func (m *runMetrics) record(status bookingStatus) {
+	if m == nil {
+		return
+	}
+
+	bookingMetrics.Inc("booking_attempts_total", "Booking attempts, including retries.", m.labels)
+	if status.attempt > 1 {
+		bookingMetrics.Inc("booking_retries_total", "Booking attempts that retried a failed attempt.", m.labels)
+	}
+
+	if status.attemptLatency > 0 {
+		bookingMetrics.Observe("booking_attempt_latency_seconds", "Time a booking attempt spent on its connection.",
+			metrics.DefaultBuckets, m.labels, status.attemptLatency.Seconds())
+	}
+
+	if status.err != nil {
+		bookingMetrics.Inc("booking_failures_total", "Failed booking attempts, by SQLSTATE.", m.with("sqlstate", sqlState(status.err)))
+		return
+	}
+
+	bookingMetrics.Inc("booking_successes_total", "Successful bookings.", m.labels)
+	bookingMetrics.Observe("booking_latency_seconds", "Time from the booking request to the successful booking, including failed attempts.",
+		metrics.DefaultBuckets, m.labels, status.latency.Seconds())
+}
+
+// stop zeroes the pool gauges once the run's pool is closed.
+func (m *runMetrics) stop() {
+	if m == nil {
+		return
+	}
+
+	m.poolGauges(func() pgpool.Stats { return pgpool.Stats{} })
+}
+
+// with returns the labels of the run along with the label name and value.
+func (m *runMetrics) with(name string, value string) metrics.Labels {
+	labels := make(metrics.Labels, len(m.labels)+1)
+	for k, v := range m.labels {
+		labels[k] = v
+	}
+	labels[name] = value
+
+	return labels
+}
+
+// sqlState classifies the error of a failed attempt by its SQLSTATE, errors that didn't come from the server
+// are classified as no_rows, timeout, canceled or other.
+func sqlState(err error) string {
+	var pgErr *pgconn2.PgError
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.Code
+	case errors.Is(err, pgx.ErrNoRows):
+		return "no_rows"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,71 @@
+package booking
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgconn2 "github.com/jackc/pgx/v5/pgconn"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	"github.com/soumya-codes/airline-reservation-poc/internal/metrics"
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
+	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+)
+
+func TestRunMetrics(t *testing.T) {
+	registry := bookingMetrics
+	bookingMetrics = metrics.NewRegistry()
+	t.Cleanup(func() { bookingMetrics = registry })
+
+	m := newRunMetrics(config.NewConfig(config.WithMaxConn(50),
+		config.WithTxIsolation(pgtx.Serializable),
+		config.WithLockStrategy(seat.GetSeatWithExclusiveLock),
+	))
+	m.poolGauges(func() pgpool.Stats { return pgpool.Stats{Size: 50, Idle: 20, InUse: 30, Waiters: 7} })
+
+	for _, status := range []bookingStatus{
+		{attempt: 1, err: &pgconn2.PgError{Code: "40001"}, attemptLatency: time.Millisecond},
+		{attempt: 2, err: errors.Join(errors.New("claim"), pgx.ErrNoRows), attemptLatency: time.Millisecond},
+		{attempt: 3, latency: 10 * time.Millisecond, attemptLatency: time.Millisecond},
+	} {
+		m.record(status)
+	}
+
+	labels := `isolation_level="SERIALIZABLE",lock_strategy="GetSeatWithExclusiveLock",pool_size="50"`
+	want := []string{
+		`booking_attempts_total{` + labels + `} 3`,
+		`booking_retries_total{` + labels + `} 2`,
+		`booking_successes_total{` + labels + `} 1`,
+		`booking_failures_total{` + labels + `,sqlstate="40001"} 1`,
+		`booking_failures_total{` + labels + `,sqlstate="no_rows"} 1`,
+		`booking_attempt_latency_seconds_count{` + labels + `} 3`,
+		`booking_latency_seconds_count{` + labels + `} 1`,
+		`booking_pool_connections{` + labels + `,state="in_use"} 30`,
+		`booking_pool_connections{` + labels + `,state="idle"} 20`,
+		`booking_pool_waiters{` + labels + `} 7`,
+	}
+	assertMetrics(t, want...)
+
+	// The gauges of a run read zero once the run's pool is closed
+	m.stop()
+	assertMetrics(t, `booking_pool_connections{`+labels+`,state="in_use"} 0`, `booking_pool_waiters{`+labels+`} 0`)
+}
+
+// assertMetrics fails the test if bookingMetrics doesn't expose the series.
+func assertMetrics(t *testing.T, series ...string) {
+	t.Helper()
+
+	var text strings.Builder
+	if err := bookingMetrics.WriteText(&text); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	for _, s := range series {
+		if !strings.Contains(text.String(), s+"\n") {
+			t.Errorf("WriteText() =\n%s\nwant it to contain %q", text.String(), s)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package booking
+
+import (
+	pgpool "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connectionpool"
+)
+
+// Priority classes of booking attempts, used with the pgpool.PriorityFIFO acquisition policy.
+const (
+	PriorityStandard pgpool.Priority = iota
+	PriorityFrequentFlyer
+	PriorityRetry
+)
+
+// RetriesFirst serves retried attempts before first attempts.
+func RetriesFirst(_ int32, attempt int) pgpool.Priority {
+	if attempt > 1 {
+		return PriorityRetry
+	}
+
+	return PriorityStandard
+}
+
+// FrequentFlyersFirst serves the given passengers before everybody else, retries of any passenger still go first.
+func FrequentFlyersFirst(passengerIDs ...int32) func(passengerID int32, attempt int) pgpool.Priority {
+	frequentFlyers := make(map[int32]struct{}, len(passengerIDs))
+	for _, id := range passengerIDs {
+		frequentFlyers[id] = struct{}{}
+	}
+
+	return func(passengerID int32, attempt int) pgpool.Priority {
+		if attempt > 1 {
+			return PriorityRetry
+		}
+
+		if _, ok := frequentFlyers[passengerID]; ok {
+			return PriorityFrequentFlyer
+		}
+
+		return PriorityStandard
+	}
+}
+
+// attemptPriority returns the pool priority of an attempt, falling back to the standard class if no classifier is set.
+func attemptPriority(priorityClass func(passengerID int32, attempt int) pgpool.Priority, passengerID int32, attempt int) pgpool.Priority {
+	if priorityClass == nil {
+		return PriorityStandard
+	}
+
+	return priorityClass(passengerID, attempt)
+}
@@ -0,0 +1,107 @@
+package booking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+	pgtrace "github.com/soumya-codes/airline-reservation-poc/internal/postgres/trace"
+)
+
+// queryTraceCapacity is the number of events kept in the trace of a run, later events are dropped.
+const queryTraceCapacity = 100_000
+
+// queryTraceSummary describes the trace of a run and where it was written.
+type queryTraceSummary struct {
+	events  int
+	dropped int
+	files   []string
+}
+
+// newQueryTrace returns the buffer the statements of the run are traced into, nil if tracing is disabled.
+func newQueryTrace(c *config.Config) *pgtrace.Buffer {
+	if c.QueryTraceDir == "" {
+		return nil
+	}
+
+	return pgtrace.NewBuffer(queryTraceCapacity)
+}
+
+// writeQueryTrace writes the trace as JSON to trace-<run id>.json, as a timeline per passenger to trace-<run id>.txt,
+// and in the Trace Event Format with a track per pool connection that opens in chrome://tracing and Perfetto
+// to trace-<run id>.chrome.json.
+func writeQueryTrace(c *config.Config, runID string, buffer *pgtrace.Buffer) (queryTraceSummary, error) {
+	if buffer == nil {
+		return queryTraceSummary{}, nil
+	}
+
+	events := buffer.Events()
+	summary := queryTraceSummary{events: len(events), dropped: buffer.Dropped()}
+	if err := os.MkdirAll(c.QueryTraceDir, 0o755); err != nil {
+		return summary, fmt.Errorf("error creating query trace directory: %w", err)
+	}
+
+	base := filepath.Join(c.QueryTraceDir, "trace-"+runID)
+	err := writeFile(base+".json", func(f *os.File) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	})
+	if err != nil {
+		return summary, err
+	}
+	summary.files = append(summary.files, base+".json")
+
+	if err := writeFile(base+".txt", func(f *os.File) error { return pgtrace.WriteTimeline(f, events) }); err != nil {
+		return summary, err
+	}
+	summary.files = append(summary.files, base+".txt")
+
+	if err := writeFile(base+".chrome.json", func(f *os.File) error { return pgtrace.WriteChromeTrace(f, events) }); err != nil {
+		return summary, err
+	}
+	summary.files = append(summary.files, base+".chrome.json")
+
+	return summary, nil
+}
+
+// clientEvent returns the trace event of the time the attempt spent waiting on the client since start, on conn if it has one.
+func clientEvent(kind pgtrace.Kind, conn *pgx.Conn, passengerID int32, attempt int, start time.Time, err error) pgtrace.Event {
+	e := pgtrace.Event{
+		Kind:        kind,
+		Name:        string(kind),
+		Start:       start,
+		Duration:    time.Since(start),
+		Slot:        -1,
+		PassengerID: passengerID,
+		Attempt:     attempt,
+	}
+
+	if conn != nil {
+		e.PID = conn.PgConn().PID()
+		if tag, ok := pgactivity.ParseTag(conn.Config().RuntimeParams["application_name"]); ok {
+			e.Slot = tag.Slot
+		}
+	}
+
+	if err != nil {
+		e.Err = err.Error()
+	}
+
+	return e
+}
+
+func printQueryTrace(summary queryTraceSummary) {
+	if len(summary.files) == 0 {
+		return
+	}
+
+	logrus.Infof("Query trace: %d events, %d dropped, written to %v", summary.events, summary.dropped, summary.files)
+}
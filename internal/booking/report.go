@@ -0,0 +1,136 @@
+package booking
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	pgserverstats "github.com/soumya-codes/airline-reservation-poc/internal/postgres/serverstats"
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+)
+
+// reportCSV is the file of the report directory the CSV row of every run is appended to.
+const reportCSV = "runs.csv"
+
+// newRunReport returns the report of the run.
+func newRunReport(c *config.Config,
+	runID string,
+	tripID int32,
+	layout cabin.Layout,
+	startedAt time.Time,
+	elapsed time.Duration,
+	latency latencyStats,
+	fairness Fairness,
+	claims claimStats,
+	taxonomy errorTaxonomy,
+	serverStats *pgserverstats.Counters,
+	seatMap *cabin.SeatMap,
+) *report.Run {
+	throughput := 0.0
+	if elapsed > 0 {
+		throughput = float64(latency.booking.Count()) / elapsed.Seconds()
+	}
+
+	run := &report.Run{
+		RunID:     runID,
+		TripID:    tripID,
+		StartedAt: startedAt,
+		Config: report.Config{
+			IsolationLevel:   string(c.TxIsolation),
+			LockStrategy:     bookingseat.Name(c.LockStrategy),
+			ClaimMode:        claimMode(c),
+			PoolSize:         c.MaxConn,
+			PoolPolicy:       string(c.PoolPolicy),
+			MaxRetries:       c.MaxRetries,
+			SavepointRetries: c.SavepointRetries,
+			QueryExecMode:    fmt.Sprint(c.PostgresConfig.QueryExecMode),
+			CabinLayout:      layout.Name,
+			SessionSettings:  c.PostgresConfig.SessionSettings.String(),
+		},
+		Elapsed:    elapsed,
+		Throughput: throughput,
+		Latency: report.Latency{
+			Booking:     latency.booking.Summary(),
+			Attempt:     latency.attempt.Summary(),
+			AcquireWait: latency.acquireWait.Summary(),
+			Commit:      latency.commit.Summary(),
+		},
+		Outcomes: report.Outcomes{
+			Passengers:         claims.passengers,
+			Bookings:           claims.bookings,
+			Attempts:           claims.attempts,
+			FailedAttempts:     taxonomy.failedAttempts(),
+			TransactionRetries: claims.transactionRetries(),
+			SavepointRetries:   claims.savepointRetries,
+			RoundTrips:         claims.roundTrips,
+			MeanLockHold:       claims.meanLockHold(),
+			MaxLockHold:        claims.maxLockHold,
+			BookedAfter:        taxonomy.bookedAfter,
+			GaveUpAfter:        taxonomy.gaveUpAfter,
+		},
+		Fairness: report.Fairness{
+			Inversions:       fairness.Inversions,
+			KendallTau:       fairness.KendallTau,
+			MeanDisplacement: fairness.MeanDisplacement,
+			MaxDisplacement:  fairness.MaxDisplacement,
+		},
+		Errors:      make([]report.ErrorCount, 0, len(taxonomy.failures)),
+		ServerStats: serverStats,
+		SeatMap:     seatMap,
+	}
+
+	for _, cause := range taxonomy.causes() {
+		count := report.ErrorCount{
+			SQLState:  cause.sqlState,
+			Name:      sqlStateNames[cause.sqlState],
+			Phase:     string(cause.phase),
+			ByAttempt: make([]int, taxonomy.maxAttempt),
+		}
+		for attempt := 1; attempt <= taxonomy.maxAttempt; attempt++ {
+			count.ByAttempt[attempt-1] = taxonomy.failures[cause][attempt]
+			count.Total += taxonomy.failures[cause][attempt]
+		}
+		run.Errors = append(run.Errors, count)
+	}
+
+	return run
+}
+
+// writeRunReport writes the report of the run to report-<run id>.json, report-<run id>.md and the seat assignment to
+// seats-<run id>.csv, and appends its CSV row to runs.csv. It returns the names of the files written.
+func writeRunReport(c *config.Config, run *report.Run) ([]string, error) {
+	if c.ReportDir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(c.ReportDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating report directory: %w", err)
+	}
+
+	var written []string
+	for _, file := range []struct {
+		name  string
+		write func(f *os.File) error
+	}{
+		{name: "report-" + run.RunID + ".json", write: func(f *os.File) error { return report.WriteJSON(f, run) }},
+		{name: "report-" + run.RunID + ".md", write: func(f *os.File) error { return report.WriteMarkdown(f, run) }},
+		{name: "seats-" + run.RunID + ".csv", write: func(f *os.File) error { return report.WriteSeatsCSV(f, run) }},
+	} {
+		name := filepath.Join(c.ReportDir, file.name)
+		if err := writeFile(name, file.write); err != nil {
+			return written, err
+		}
+		written = append(written, name)
+	}
+
+	name := filepath.Join(c.ReportDir, reportCSV)
+	if err := report.AppendCSV(name, run); err != nil {
+		return written, err
+	}
+
+	return append(written, name), nil
+}
@@ -72,3 +72,22 @@ func GetSeatWithExclusiveLockSkipped(ctx context.Context, q *store.Queries, trip
 		SeatID: s.SeatID,
 	}, nil
 }
+
+// BookSeatWithFunction looks up, locks and assigns the next available seat of the trip to the passenger with a single
+// call to the book_seat function, which runs the whole claim inside Postgres. lockMode is the lock taken on the seat
+// while looking it up, see LockMode.
+func BookSeatWithFunction(ctx context.Context, q *store.Queries, tripID int32, passengerID int32, lockMode string) (*Seat, error) {
+	s, err := q.BookSeatWithFunction(ctx, store.BookSeatWithFunctionParams{
+		TripID:      tripID,
+		PassengerID: passengerID,
+		LockMode:    lockMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Seat{
+		ID:     s.Identifier,
+		SeatID: s.SeatID,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package seat
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// PipelinedClaim queues a statement on the batch that looks up the next available seat of the trip and assigns it
+// to the passenger in a single statement, so the claim can be sent together with BEGIN and COMMIT in one round trip.
+// The claimed seat is stored in s when the batch results are read.
+type PipelinedClaim func(b *pgx.Batch, tripID int32, passengerID int32, s *Seat)
+
+func ClaimSeatWithNoLock(b *pgx.Batch, tripID int32, passengerID int32, s *Seat) {
+	queueClaim(b, store.ClaimSeatWithNoLock, tripID, passengerID, s)
+}
+
+func ClaimSeatWithSharedLock(b *pgx.Batch, tripID int32, passengerID int32, s *Seat) {
+	queueClaim(b, store.ClaimSeatWithSharedLock, tripID, passengerID, s)
+}
+
+func ClaimSeatWithSharedLockSkipped(b *pgx.Batch, tripID int32, passengerID int32, s *Seat) {
+	queueClaim(b, store.ClaimSeatWithSharedLockSkipped, tripID, passengerID, s)
+}
+
+func ClaimSeatWithExclusiveLock(b *pgx.Batch, tripID int32, passengerID int32, s *Seat) {
+	queueClaim(b, store.ClaimSeatWithExclusiveLock, tripID, passengerID, s)
+}
+
+func ClaimSeatWithExclusiveLockSkipped(b *pgx.Batch, tripID int32, passengerID int32, s *Seat) {
+	queueClaim(b, store.ClaimSeatWithExclusiveLockSkipped, tripID, passengerID, s)
+}
+
+func queueClaim(b *pgx.Batch, query string, tripID int32, passengerID int32, s *Seat) {
+	b.Queue(query, tripID, passengerID).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&s.ID, &s.SeatID)
+	})
+}
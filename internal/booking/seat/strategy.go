@@ -0,0 +1,124 @@
+package seat
+
+import (
+	"reflect"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// strategies lists the lock strategies shipped with this package along with their names, pipelined counterparts,
+// the lock modes of the book_seat function that lock seats the same way and the store queries they run.
+var strategies = []struct {
+	name           string
+	lockStrategy   LockStrategy
+	pipelinedClaim PipelinedClaim
+	lockMode       string
+	queries        Queries
+}{
+	{
+		name:           "GetSeatWithNoLock",
+		lockStrategy:   GetSeatWithNoLock,
+		pipelinedClaim: ClaimSeatWithNoLock,
+		lockMode:       "NONE",
+		queries:        Queries{Lookup: store.GetSeatWithNoLock, Claim: store.ClaimSeatWithNoLock},
+	},
+	{
+		name:           "GetSeatWithSharedLock",
+		lockStrategy:   GetSeatWithSharedLock,
+		pipelinedClaim: ClaimSeatWithSharedLock,
+		lockMode:       "SHARE",
+		queries:        Queries{Lookup: store.GetSeatWithSharedLock, Claim: store.ClaimSeatWithSharedLock},
+	},
+	{
+		name:           "GetSeatWithSharedLockSkipped",
+		lockStrategy:   GetSeatWithSharedLockSkipped,
+		pipelinedClaim: ClaimSeatWithSharedLockSkipped,
+		lockMode:       "SHARE SKIP LOCKED",
+		queries:        Queries{Lookup: store.GetSeatWithSharedLockSkipped, Claim: store.ClaimSeatWithSharedLockSkipped},
+	},
+	{
+		name:           "GetSeatWithExclusiveLock",
+		lockStrategy:   GetSeatWithExclusiveLock,
+		pipelinedClaim: ClaimSeatWithExclusiveLock,
+		lockMode:       "UPDATE",
+		queries:        Queries{Lookup: store.GetSeatWithExclusiveLock, Claim: store.ClaimSeatWithExclusiveLock},
+	},
+	{
+		name:           "GetSeatWithExclusiveLockSkipped",
+		lockStrategy:   GetSeatWithExclusiveLockSkipped,
+		pipelinedClaim: ClaimSeatWithExclusiveLockSkipped,
+		lockMode:       "UPDATE SKIP LOCKED",
+		queries:        Queries{Lookup: store.GetSeatWithExclusiveLockSkipped, Claim: store.ClaimSeatWithExclusiveLockSkipped},
+	},
+}
+
+// Queries are the store queries of a lock strategy: Lookup takes the trip ID and returns the next available seat,
+// Claim takes the trip ID and the passenger ID and assigns the next available seat in a single statement.
+// A client-side booking runs Lookup followed by store.BookSeat, a pipelined booking runs Claim.
+type Queries struct {
+	Lookup string
+	Claim  string
+}
+
+// Strategies returns the lock strategies shipped with this package.
+func Strategies() []LockStrategy {
+	lockStrategies := make([]LockStrategy, 0, len(strategies))
+	for _, s := range strategies {
+		lockStrategies = append(lockStrategies, s.lockStrategy)
+	}
+
+	return lockStrategies
+}
+
+// QueriesFor returns the store queries run by the lock strategy, if it is one of the strategies of this package.
+func QueriesFor(strategy LockStrategy) (Queries, bool) {
+	if i := lookup(strategy); i >= 0 {
+		return strategies[i].queries, true
+	}
+
+	return Queries{}, false
+}
+
+// Name returns the name of the lock strategy, or "custom" if it isn't one of the strategies of this package.
+func Name(strategy LockStrategy) string {
+	if i := lookup(strategy); i >= 0 {
+		return strategies[i].name
+	}
+
+	return "custom"
+}
+
+// PipelinedClaimFor returns the pipelined counterpart of the lock strategy, if it has one.
+func PipelinedClaimFor(strategy LockStrategy) (PipelinedClaim, bool) {
+	if i := lookup(strategy); i >= 0 {
+		return strategies[i].pipelinedClaim, true
+	}
+
+	return nil, false
+}
+
+// LockMode returns the lock mode of the book_seat function that locks seats the same way as the lock strategy, if any.
+func LockMode(strategy LockStrategy) (string, bool) {
+	if i := lookup(strategy); i >= 0 {
+		return strategies[i].lockMode, true
+	}
+
+	return "", false
+}
+
+// lookup returns the index of the strategy in strategies, or -1. Functions can't be compared in Go, so they are
+// compared by their entry points.
+func lookup(strategy LockStrategy) int {
+	if strategy == nil {
+		return -1
+	}
+
+	pc := reflect.ValueOf(strategy).Pointer()
+	for i, s := range strategies {
+		if reflect.ValueOf(s.lockStrategy).Pointer() == pc {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,34 @@
+package booking
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+)
+
+// writeSeatMap writes the seat map of the trip to seatmap-<run id>.txt and seatmap-<run id>.json,
+// it returns the names of the files written.
+func writeSeatMap(c *config.Config, runID string, seatMap *cabin.SeatMap) ([]string, error) {
+	if c.SeatMapDir == "" || seatMap == nil {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(c.SeatMapDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating seat map directory: %w", err)
+	}
+
+	text := filepath.Join(c.SeatMapDir, "seatmap-"+runID+".txt")
+	if err := writeFile(text, func(f *os.File) error { return seatMap.WriteText(f) }); err != nil {
+		return nil, err
+	}
+
+	jsonFile := filepath.Join(c.SeatMapDir, "seatmap-"+runID+".json")
+	if err := writeFile(jsonFile, func(f *os.File) error { return seatMap.WriteJSON(f) }); err != nil {
+		return []string{text}, err
+	}
+
+	return []string{text, jsonFile}, nil
+}
@@ -0,0 +1,89 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+	pgserverstats "github.com/soumya-codes/airline-reservation-poc/internal/postgres/serverstats"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+const (
+	// reservationTable is the table the bookings update.
+	reservationTable = "reservation"
+	// backendExitTimeout is how long to wait for the backends of the run to exit and flush their statistics.
+	backendExitTimeout = 2 * time.Second
+)
+
+// snapshotServerStats reads the server statistics on conn. The statistics only complement the run,
+// so a failure to read them is logged and reported as not ok instead of failing the run.
+func snapshotServerStats(ctx context.Context, conn *pgx.Conn) (pgserverstats.Counters, bool) {
+	counters, err := pgserverstats.Snapshot(ctx, store.New(conn), reservationTable)
+	if err != nil {
+		logrus.WithError(err).Warn("error reading server statistics")
+		return pgserverstats.Counters{}, false
+	}
+
+	return counters, true
+}
+
+// serverStatsDelta waits for the backends of the run to exit, so their statistics are flushed,
+// and returns the change of the server statistics since before.
+func serverStatsDelta(ctx context.Context, conn *pgx.Conn, runID string, before pgserverstats.Counters) *pgserverstats.Counters {
+	// The run's context may have expired with the bookings, the statistics are read regardless
+	ctx = context.WithoutCancel(ctx)
+	if err := pgactivity.WaitForExit(ctx, conn, runID, backendExitTimeout); err != nil {
+		logrus.WithError(err).Warn("server statistics may miss the statistics of backends still running")
+	}
+
+	after, ok := snapshotServerStats(ctx, conn)
+	if !ok {
+		return nil
+	}
+
+	delta := pgserverstats.Delta(before, after)
+	return &delta
+}
+
+func printServerStats(delta *pgserverstats.Counters, claims claimStats) {
+	if delta == nil {
+		return
+	}
+
+	logrus.Infof("Server statistics: commits: %d, rollbacks: %d (client: %d failed attempts), deadlocks: %d, recovery conflicts: %d, tuples updated: %d, WAL generated: %s",
+		delta.XactCommit,
+		delta.XactRollback,
+		claims.attempts-claims.bookings,
+		delta.Deadlocks,
+		delta.Conflicts,
+		delta.TupUpdated,
+		formatBytes(delta.WALBytes),
+	)
+
+	logrus.Infof("Table %s: updates: %d, HOT updates: %d (%.1f%%), dead tuples: %+d",
+		delta.Table.Name,
+		delta.Table.Updates,
+		delta.Table.HOTUpdates,
+		delta.HOTRatio()*100,
+		delta.Table.DeadTuples,
+	)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
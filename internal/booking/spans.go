@@ -0,0 +1,107 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	bookingseat "github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	"github.com/soumya-codes/airline-reservation-poc/internal/telemetry"
+)
+
+const (
+	// spanCapacity is the number of spans kept of a run, later spans are dropped.
+	spanCapacity = 100_000
+	// serviceName is the service the spans are reported under.
+	serviceName = "airline-reservation-poc"
+)
+
+// spanSummary describes the spans of a run and where they were written.
+type spanSummary struct {
+	spans   int
+	dropped int
+	traceID string
+	file    string
+}
+
+// newSpanTracer returns the tracer the spans of the run are recorded by, nil if spans are disabled.
+func newSpanTracer(c *config.Config) *telemetry.Tracer {
+	if c.SpanDir == "" {
+		return nil
+	}
+
+	return telemetry.NewTracer(spanCapacity)
+}
+
+// startRunSpan starts the root span of the run, its context carries the span to the booking tasks.
+func startRunSpan(ctx context.Context, c *config.Config, tracer *telemetry.Tracer) (context.Context, *telemetry.Span) {
+	return tracer.Start(ctx, "BookSeats",
+		telemetry.String("db.isolation_level", string(c.TxIsolation)),
+		telemetry.String("booking.lock_strategy", bookingseat.Name(c.LockStrategy)),
+		telemetry.String("booking.mode", string(bookingMode(c))),
+		telemetry.Int("pool.size", int64(c.MaxConn)),
+		telemetry.String("pool.policy", string(c.PoolPolicy)),
+	)
+}
+
+// startAttemptSpan starts the span of a booking attempt, a child of the passenger's booking task span.
+func startAttemptSpan(ctx context.Context, c *config.Config, tripID int32, passengerID int32, attempt int) (context.Context, *telemetry.Span) {
+	return telemetry.Start(ctx, "bookSeatAttempt",
+		telemetry.Int("passenger.id", int64(passengerID)),
+		telemetry.Int("trip.id", int64(tripID)),
+		telemetry.Int("booking.attempt", int64(attempt)),
+		telemetry.String("db.isolation_level", string(c.TxIsolation)),
+	)
+}
+
+// endBookingSpan ends the span of a booking task or attempt with the seat it claimed, or its error and SQLSTATE.
+func endBookingSpan(span *telemetry.Span, status bookingStatus) {
+	if status.seatId != "" {
+		span.SetAttributes(telemetry.String("seat.id", status.seatId))
+	}
+
+	if status.err != nil {
+		span.SetAttributes(telemetry.String("db.sqlstate", sqlState(status.err)))
+		span.RecordError(status.err)
+	}
+
+	span.End()
+}
+
+// writeSpans writes the spans recorded so far as OTLP JSON to spans-<run id>.otlp.json.
+func writeSpans(c *config.Config, runID string, tracer *telemetry.Tracer) (spanSummary, error) {
+	if tracer == nil {
+		return spanSummary{}, nil
+	}
+
+	spans := tracer.Spans()
+	summary := spanSummary{spans: len(spans), dropped: tracer.Dropped()}
+	for _, s := range spans {
+		if !s.Parent.IsValid() && s.Name == "BookSeats" {
+			summary.traceID = s.TraceID.String()
+		}
+	}
+
+	if err := os.MkdirAll(c.SpanDir, 0o755); err != nil {
+		return summary, fmt.Errorf("error creating span directory: %w", err)
+	}
+
+	name := filepath.Join(c.SpanDir, "spans-"+runID+".otlp.json")
+	if err := writeFile(name, func(f *os.File) error { return telemetry.WriteOTLP(f, serviceName, spans) }); err != nil {
+		return summary, err
+	}
+	summary.file = name
+
+	return summary, nil
+}
+
+func printSpans(summary spanSummary) {
+	if summary.file == "" {
+		return
+	}
+
+	logrus.Infof("Spans: %d spans of trace %s, %d dropped, written to %s", summary.spans, summary.traceID, summary.dropped, summary.file)
+}
@@ -0,0 +1,54 @@
+package booking
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/config"
+	pgstatements "github.com/soumya-codes/airline-reservation-poc/internal/postgres/statements"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// resetStatementStats resets pg_stat_statements before the run, if statement statistics are enabled.
+// It reports whether the statistics were reset, e.g. it reports false if the extension isn't installed.
+func resetStatementStats(ctx context.Context, c *config.Config, conn *pgx.Conn) bool {
+	if !c.StatementStats {
+		return false
+	}
+
+	if err := pgstatements.Reset(ctx, store.New(conn)); err != nil {
+		logrus.WithError(err).Warn("statement statistics disabled, is pg_stat_statements installed?")
+		return false
+	}
+
+	return true
+}
+
+func readStatementStats(ctx context.Context, conn *pgx.Conn) []pgstatements.Statement {
+	statements, err := pgstatements.Read(context.WithoutCancel(ctx), store.New(conn))
+	if err != nil {
+		logrus.WithError(err).Warn("error reading statement statistics")
+		return nil
+	}
+
+	return statements
+}
+
+func printStatementStats(statements []pgstatements.Statement) {
+	if len(statements) == 0 {
+		return
+	}
+
+	logrus.Info("Statement statistics from pg_stat_statements, by total execution time:")
+	for _, s := range statements {
+		logrus.Infof("%-36s calls: %6d, total: %12v, mean: %10v, max: %10v, rows: %6d",
+			s.Name,
+			s.Calls,
+			s.TotalTime,
+			s.MeanTime(),
+			s.MaxTime,
+			s.Rows,
+		)
+	}
+}
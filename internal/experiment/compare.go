@@ -0,0 +1,428 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Selector selects the recorded runs to compare: a single run by its run ID, or the most recent runs of a configuration,
+// written as "<isolation level>/<lock strategy>/<pool size>[,<key>=<value>...][@<git sha prefix>]",
+// e.g. "READ COMMITTED/GetSeatWithExclusiveLock/50,claim=pipelined,layout=wide-body@4bf7".
+// The keys narrow the configuration down, see selectorKeys.
+type Selector struct {
+	RunID          string
+	IsolationLevel string
+	LockStrategy   string
+	PoolSize       int32
+	// The settings below select the runs of any value when they are left empty, or 0.
+	ClaimMode       string
+	PoolPolicy      string
+	MaxRetries      int32
+	QueryExecMode   string
+	CabinLayout     string
+	SessionSettings string
+	// ServerSettings is the prefix of the hash of the server settings of the runs.
+	ServerSettings string
+	// GitSHA is the prefix of the git SHA of the runs, an empty GitSHA selects the runs of any commit.
+	GitSHA string
+}
+
+// selectorKeys are the keys of the settings a configuration can be narrowed down by, in the order they are written.
+var selectorKeys = []string{"claim", "policy", "retries", "exec", "layout", "session", "settings"}
+
+// setting returns the setting of the selector with the key.
+func (s *Selector) setting(key string) *string {
+	switch key {
+	case "claim":
+		return &s.ClaimMode
+	case "policy":
+		return &s.PoolPolicy
+	case "exec":
+		return &s.QueryExecMode
+	case "layout":
+		return &s.CabinLayout
+	case "session":
+		return &s.SessionSettings
+	case "settings":
+		return &s.ServerSettings
+	default:
+		return nil
+	}
+}
+
+// ParseSelector parses a run ID or a configuration.
+func ParseSelector(s string) (Selector, error) {
+	if !strings.Contains(s, "/") {
+		if s == "" {
+			return Selector{}, errors.New("empty run selector")
+		}
+		return Selector{RunID: s}, nil
+	}
+
+	configuration, gitSHA, _ := strings.Cut(s, "@")
+	qualifiers := strings.Split(configuration, ",")
+	parts := strings.Split(qualifiers[0], "/")
+	if len(parts) != 3 {
+		return Selector{}, fmt.Errorf("invalid configuration %q, expected <isolation level>/<lock strategy>/<pool size>[,<key>=<value>...][@<git sha>]", s)
+	}
+
+	poolSize, err := strconv.Atoi(parts[2])
+	if err != nil || poolSize <= 0 {
+		return Selector{}, fmt.Errorf("invalid pool size %q in configuration %q", parts[2], s)
+	}
+
+	selector := Selector{IsolationLevel: parts[0], LockStrategy: parts[1], PoolSize: int32(poolSize), GitSHA: gitSHA}
+	for _, qualifier := range qualifiers[1:] {
+		key, value, ok := strings.Cut(qualifier, "=")
+		if !ok || value == "" {
+			return Selector{}, fmt.Errorf("invalid setting %q in configuration %q, expected <key>=<value>", qualifier, s)
+		}
+
+		if key == "retries" {
+			retries, err := strconv.Atoi(value)
+			if err != nil || retries <= 0 {
+				return Selector{}, fmt.Errorf("invalid retries %q in configuration %q", value, s)
+			}
+			selector.MaxRetries = int32(retries)
+			continue
+		}
+
+		setting := selector.setting(key)
+		if setting == nil {
+			return Selector{}, fmt.Errorf("unknown setting %q in configuration %q, expected one of %s", key, s, strings.Join(selectorKeys, ", "))
+		}
+		*setting = value
+	}
+
+	return selector, nil
+}
+
+func (s Selector) String() string {
+	if s.RunID != "" {
+		return s.RunID
+	}
+
+	name := fmt.Sprintf("%s/%s/%d", s.IsolationLevel, s.LockStrategy, s.PoolSize)
+	for _, key := range selectorKeys {
+		value := ""
+		if key == "retries" {
+			if s.MaxRetries > 0 {
+				value = strconv.Itoa(int(s.MaxRetries))
+			}
+		} else {
+			value = *s.setting(key)
+		}
+
+		if value != "" {
+			name += "," + key + "=" + value
+		}
+	}
+
+	if s.GitSHA != "" {
+		name += "@" + s.GitSHA
+	}
+
+	return name
+}
+
+// configuration returns the settings of the run beyond those every selector names, by selector key.
+// The runs compared as one configuration must agree on all of them.
+func configuration(run store.ExperimentRun) [][2]string {
+	return [][2]string{
+		{"claim", run.ClaimMode},
+		{"policy", run.PoolPolicy},
+		{"retries", strconv.Itoa(int(run.MaxRetries))},
+		{"exec", run.QueryExecMode},
+		{"layout", run.CabinLayout},
+		{"session", run.SessionSettings},
+		{"settings", run.ServerSettingsHash},
+	}
+}
+
+// checkConfiguration returns an error if the runs of the selector differ in a setting, so runs of different
+// configurations are never averaged together.
+func checkConfiguration(selector Selector, runs []store.ExperimentRun) error {
+	first := configuration(runs[0])
+	for _, run := range runs[1:] {
+		for i, setting := range configuration(run) {
+			if setting[1] != first[i][1] {
+				return fmt.Errorf("the runs of %s differ in %s, %q and %q, select one of them by adding ,%s=<value>",
+					selector, setting[0], first[i][1], setting[1], setting[0])
+			}
+		}
+	}
+
+	return nil
+}
+
+// Summary is the throughput, p99 latency and failure rate of the selected runs.
+type Summary struct {
+	Selector Selector
+	Runs     []store.ExperimentRun
+	// Throughput is the mean throughput of the runs, in bookings per second.
+	Throughput float64
+	// P99 is the mean p99 booking latency of the runs.
+	P99 time.Duration
+	// FailureRate is the share of the attempts of all the runs that failed.
+	FailureRate float64
+}
+
+// Summarize returns the summary of the runs.
+func Summarize(selector Selector, runs []store.ExperimentRun) Summary {
+	s := Summary{Selector: selector, Runs: runs}
+	if len(runs) == 0 {
+		return s
+	}
+
+	var p99 int64
+	var attempts, failed int32
+	for _, run := range runs {
+		s.Throughput += run.Throughput / float64(len(runs))
+		p99 += run.BookingP99Us
+		attempts += run.Attempts
+		failed += run.FailedAttempts
+	}
+
+	s.P99 = time.Duration(p99/int64(len(runs))) * time.Microsecond
+	if attempts > 0 {
+		s.FailureRate = float64(failed) / float64(attempts)
+	}
+
+	return s
+}
+
+// Select reads the runs of the selector, at most maxRuns of a configuration, the most recent first.
+func Select(ctx context.Context, q *store.Queries, selector Selector, maxRuns int32) (Summary, error) {
+	if selector.RunID != "" {
+		run, err := q.GetExperimentRun(ctx, selector.RunID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Summary{}, fmt.Errorf("no recorded run %s", selector.RunID)
+		}
+		if err != nil {
+			return Summary{}, fmt.Errorf("error reading experiment run %s: %w", selector.RunID, err)
+		}
+
+		return Summarize(selector, []store.ExperimentRun{run}), nil
+	}
+
+	runs, err := q.GetExperimentRunsByConfiguration(ctx, store.GetExperimentRunsByConfigurationParams{
+		IsolationLevel:            selector.IsolationLevel,
+		LockStrategy:              selector.LockStrategy,
+		PoolSize:                  selector.PoolSize,
+		ClaimMode:                 selector.ClaimMode,
+		PoolPolicy:                selector.PoolPolicy,
+		MaxRetries:                selector.MaxRetries,
+		QueryExecMode:             selector.QueryExecMode,
+		CabinLayout:               selector.CabinLayout,
+		SessionSettings:           selector.SessionSettings,
+		ServerSettingsHashPattern: selector.ServerSettings + "%",
+		GitShaPattern:             selector.GitSHA + "%",
+		MaxRuns:                   maxRuns,
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("error reading the experiment runs of %s: %w", selector, err)
+	}
+	if len(runs) == 0 {
+		return Summary{}, fmt.Errorf("no recorded runs of %s", selector)
+	}
+	if err := checkConfiguration(selector, runs); err != nil {
+		return Summary{}, err
+	}
+
+	return Summarize(selector, runs), nil
+}
+
+// Thresholds are the changes from the baseline to the candidate that are reported as regressions.
+type Thresholds struct {
+	// Throughput is the relative drop of the throughput, e.g. 0.1 for 10%.
+	Throughput float64
+	// P99 is the relative increase of the p99 booking latency, e.g. 0.1 for 10%.
+	P99 float64
+	// FailureRate is the increase of the failure rate in percentage points, e.g. 1 from 2% to more than 3%.
+	FailureRate float64
+}
+
+// DefaultThresholds report a 10% drop of the throughput, a 10% increase of the p99 latency and an increase of the
+// failure rate by a percentage point as regressions.
+var DefaultThresholds = Thresholds{Throughput: 0.1, P99: 0.1, FailureRate: 1}
+
+// Metric is a metric of the baseline and of the candidate.
+type Metric struct {
+	Name      string
+	Unit      string
+	Baseline  float64
+	Candidate float64
+	// Regression reports whether the candidate is worse than the baseline by more than the metric's threshold.
+	Regression bool
+}
+
+// Change returns the change of the candidate from the baseline, relative for the throughput and the latency,
+// in percentage points for the failure rate. It returns 0 for a relative change from 0.
+func (m Metric) Change() float64 {
+	if m.Unit == "%" {
+		return m.Candidate - m.Baseline
+	}
+
+	if m.Baseline == 0 {
+		return 0
+	}
+
+	return (m.Candidate - m.Baseline) / m.Baseline
+}
+
+// Compare returns the throughput, p99 latency and failure rate of the baseline and the candidate,
+// and whether the candidate regressed.
+func Compare(baseline, candidate Summary, t Thresholds) []Metric {
+	throughput := Metric{Name: "throughput", Unit: "bookings/s", Baseline: baseline.Throughput, Candidate: candidate.Throughput}
+	throughput.Regression = throughput.Change() < -t.Throughput
+
+	p99 := Metric{Name: "p99 latency", Unit: "ms", Baseline: milliseconds(baseline.P99), Candidate: milliseconds(candidate.P99)}
+	p99.Regression = p99.Change() > t.P99
+
+	failureRate := Metric{Name: "failure rate", Unit: "%", Baseline: 100 * baseline.FailureRate, Candidate: 100 * candidate.FailureRate}
+	failureRate.Regression = failureRate.Change() > t.FailureRate
+
+	return []Metric{throughput, p99, failureRate}
+}
+
+// Regressions returns the number of metrics that regressed.
+func Regressions(metrics []Metric) int {
+	n := 0
+	for _, m := range metrics {
+		if m.Regression {
+			n++
+		}
+	}
+
+	return n
+}
+
+// WriteComparison writes the selected runs and a line per metric, the regressions are flagged with REGRESSION.
+func WriteComparison(w io.Writer, baseline, candidate Summary, metrics []Metric) error {
+	var b strings.Builder
+	for _, s := range []struct {
+		name    string
+		summary Summary
+	}{
+		{name: "baseline", summary: baseline},
+		{name: "candidate", summary: candidate},
+	} {
+		fmt.Fprintf(&b, "%-10s %s: %s\n", s.name, s.summary.Selector, describeRuns(s.summary.Runs))
+		if len(s.summary.Runs) > 0 {
+			fmt.Fprintf(&b, "%-10s %s\n", "", describeConfiguration(s.summary.Runs[0]))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%-14s %14s %14s %10s\n", "metric", "baseline", "candidate", "change")
+	for _, m := range metrics {
+		change := fmt.Sprintf("%+.1f%%", 100*m.Change())
+		if m.Unit == "%" {
+			change = fmt.Sprintf("%+.1f pts", m.Change())
+		}
+
+		line := fmt.Sprintf("%-14s %14s %14s %10s", m.Name, formatValue(m.Baseline, m.Unit), formatValue(m.Candidate, m.Unit), change)
+		if m.Regression {
+			line += "  REGRESSION"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describeRuns describes the runs, e.g. "3 runs from 2024-07-01 10:00 to 2024-07-02 09:30, git 4bf7372".
+func describeRuns(runs []store.ExperimentRun) string {
+	if len(runs) == 0 {
+		return "no runs"
+	}
+
+	first, last := runs[0].StartedAt, runs[0].StartedAt
+	shas := make(map[string]bool)
+	var gitSHAs []string
+	for _, run := range runs {
+		first = minTime(first, run.StartedAt)
+		last = maxTime(last, run.StartedAt)
+		sha := shortSHA(run.GitSha)
+		if !shas[sha] {
+			shas[sha] = true
+			gitSHAs = append(gitSHAs, sha)
+		}
+	}
+
+	const layout = "2006-01-02 15:04"
+	if len(runs) == 1 {
+		return fmt.Sprintf("run %s at %s, git %s", runs[0].RunID, first.Format(layout), strings.Join(gitSHAs, ", "))
+	}
+
+	return fmt.Sprintf("%d runs from %s to %s, git %s", len(runs), first.Format(layout), last.Format(layout), strings.Join(gitSHAs, ", "))
+}
+
+// describeConfiguration describes the settings of the run beyond those of its selector, e.g.
+// "claim=transaction, policy=FIFO, retries=3, exec=cache_statement, layout=narrow-body, session=..., settings=0a1b2c3d".
+func describeConfiguration(run store.ExperimentRun) string {
+	settings := configuration(run)
+	parts := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		value := setting[1]
+		if setting[0] == "settings" && len(value) > 8 {
+			value = value[:8]
+		}
+		parts = append(parts, setting[0]+"="+value)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func shortSHA(sha string) string {
+	if sha == "" {
+		return "unknown"
+	}
+
+	commit, dirty := strings.CutSuffix(sha, "-dirty")
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if dirty {
+		commit += "-dirty"
+	}
+
+	return commit
+}
+
+func formatValue(v float64, unit string) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.2f%%", v)
+	case "ms":
+		return fmt.Sprintf("%.2f ms", v)
+	default:
+		return fmt.Sprintf("%.1f/s", v)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
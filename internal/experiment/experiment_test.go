@@ -0,0 +1,125 @@
+package experiment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+func TestParseSelector(t *testing.T) {
+	for _, tt := range []struct {
+		s       string
+		want    Selector
+		wantErr bool
+	}{
+		{s: "20240701T100000-ab12", want: Selector{RunID: "20240701T100000-ab12"}},
+		{s: "READ COMMITTED/GetSeatWithExclusiveLock/50", want: Selector{IsolationLevel: "READ COMMITTED", LockStrategy: "GetSeatWithExclusiveLock", PoolSize: 50}},
+		{s: "SERIALIZABLE/GetSeatWithNoLock/10@4bf7", want: Selector{IsolationLevel: "SERIALIZABLE", LockStrategy: "GetSeatWithNoLock", PoolSize: 10, GitSHA: "4bf7"}},
+		{
+			s: "SERIALIZABLE/GetSeatWithNoLock/10,claim=pipelined,retries=3,exec=simple_protocol,layout=wide-body,session=lock_timeout=1s statement_timeout=default,settings=0a1b@4bf7",
+			want: Selector{IsolationLevel: "SERIALIZABLE", LockStrategy: "GetSeatWithNoLock", PoolSize: 10, ClaimMode: "pipelined", MaxRetries: 3,
+				QueryExecMode: "simple_protocol", CabinLayout: "wide-body", SessionSettings: "lock_timeout=1s statement_timeout=default", ServerSettings: "0a1b", GitSHA: "4bf7"},
+		},
+		{s: "SERIALIZABLE/GetSeatWithNoLock/10,isolation=SERIALIZABLE", wantErr: true},
+		{s: "SERIALIZABLE/GetSeatWithNoLock/10,retries=none", wantErr: true},
+		{s: "SERIALIZABLE/GetSeatWithNoLock/10,layout", wantErr: true},
+		{s: "READ COMMITTED/GetSeatWithNoLock", wantErr: true},
+		{s: "READ COMMITTED/GetSeatWithNoLock/many", wantErr: true},
+		{s: "", wantErr: true},
+	} {
+		got, err := ParseSelector(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSelector(%q) = %+v, %v, want %+v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+
+		if err == nil && got.String() != tt.s {
+			t.Errorf("String() = %q, want %q", got.String(), tt.s)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	started := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	baseline := Summarize(Selector{IsolationLevel: "READ COMMITTED", LockStrategy: "GetSeatWithExclusiveLockSkipped", PoolSize: 50}, []store.ExperimentRun{
+		{RunID: "r1", GitSha: "4bf7372aaaaaaaa", StartedAt: started, Throughput: 100, BookingP99Us: 20000, Attempts: 190, FailedAttempts: 10},
+		{RunID: "r2", GitSha: "4bf7372aaaaaaaa", StartedAt: started.Add(time.Hour), Throughput: 120, BookingP99Us: 30000, Attempts: 210, FailedAttempts: 10},
+	})
+	candidate := Summarize(Selector{RunID: "r3"}, []store.ExperimentRun{
+		{RunID: "r3", GitSha: "2068be2bbbbbbbb-dirty", StartedAt: started.Add(2 * time.Hour), Throughput: 99, BookingP99Us: 26000, Attempts: 200, FailedAttempts: 18,
+			ClaimMode: "pipelined", PoolPolicy: "FIFO", MaxRetries: 3, QueryExecMode: "cache_statement", CabinLayout: "narrow-body",
+			SessionSettings: "lock_timeout=1s", ServerSettingsHash: "0a1b2c3d4e5f"},
+	})
+
+	if baseline.Throughput != 110 || baseline.P99 != 25*time.Millisecond || baseline.FailureRate != 0.05 {
+		t.Fatalf("Summarize() = %+v, want the mean throughput and p99 and the failure rate of all the attempts", baseline)
+	}
+
+	metrics := Compare(baseline, candidate, DefaultThresholds)
+	for i, regression := range []bool{false, false, true} {
+		if metrics[i].Regression != regression {
+			t.Errorf("%s regression = %v, want %v", metrics[i].Name, metrics[i].Regression, regression)
+		}
+	}
+
+	// A 10% drop of the throughput is a regression with a tighter threshold
+	metrics = Compare(baseline, candidate, Thresholds{Throughput: 0.05, P99: 0.1, FailureRate: 5})
+	if Regressions(metrics) != 1 || !metrics[0].Regression {
+		t.Errorf("Compare() = %+v, want only the throughput to regress", metrics)
+	}
+
+	var out strings.Builder
+	if err := WriteComparison(&out, baseline, candidate, Compare(baseline, candidate, DefaultThresholds)); err != nil {
+		t.Fatalf("WriteComparison() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"baseline   READ COMMITTED/GetSeatWithExclusiveLockSkipped/50: 2 runs from 2024-07-01 10:00 to 2024-07-01 11:00, git 4bf7372",
+		"candidate  r3: run r3 at 2024-07-01 12:00, git 2068be2-dirty",
+		"           claim=pipelined, policy=FIFO, retries=3, exec=cache_statement, layout=narrow-body, session=lock_timeout=1s, settings=0a1b2c3d\n",
+		"throughput            110.0/s         99.0/s     -10.0%\n",
+		"failure rate            5.00%          9.00%   +4.0 pts  REGRESSION",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteComparison() =\n%s\nwant it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestRunParams(t *testing.T) {
+	run := &report.Run{
+		RunID:     "r1",
+		StartedAt: time.Date(2024, 7, 1, 15, 30, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+		Config:    report.Config{IsolationLevel: "SERIALIZABLE", PoolSize: 50},
+		Elapsed:   1500 * time.Millisecond,
+	}
+	run.Latency.Booking.P99 = 12 * time.Millisecond
+
+	params := RunParams(run, "4bf7372", store.GetServerSettingsRow{SettingsHash: "0a1b2c3d", Settings: []byte(`{"fsync": "on"}`)})
+	if params.StartedAt != time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC) || params.ElapsedUs != 1500000 || params.BookingP99Us != 12000 || params.PoolSize != 50 {
+		t.Errorf("RunParams() = %+v, want the start in UTC and the durations in microseconds", params)
+	}
+
+	if params.ServerSettingsHash != "0a1b2c3d" || string(params.ServerSettings) != `{"fsync": "on"}` {
+		t.Errorf("RunParams() = %+v, want the server settings of the run", params)
+	}
+}
+
+func TestCheckConfiguration(t *testing.T) {
+	selector := Selector{IsolationLevel: "READ COMMITTED", LockStrategy: "GetSeatWithNoLock", PoolSize: 10}
+	runs := []store.ExperimentRun{
+		{RunID: "r1", ClaimMode: "transaction", CabinLayout: "narrow-body", ServerSettingsHash: "0a1b"},
+		{RunID: "r2", ClaimMode: "transaction", CabinLayout: "narrow-body", ServerSettingsHash: "0a1b"},
+	}
+	if err := checkConfiguration(selector, runs); err != nil {
+		t.Errorf("checkConfiguration() of the runs of a configuration error = %v", err)
+	}
+
+	runs[1].ServerSettingsHash = "ffff"
+	err := checkConfiguration(selector, runs)
+	if err == nil || !strings.Contains(err.Error(), "differ in settings") {
+		t.Errorf("checkConfiguration() of runs with different server settings error = %v, want them told apart", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Package experiment records the history of the booking runs in the experiment_run and experiment_attempt tables,
+// and compares two runs, or the recent runs of two configurations, to highlight regressions.
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// serverSettingNames are the server settings recorded by name with every run, the ones that bear on the bookings.
+// Every other setting only shows in the hash of the server settings.
+var serverSettingNames = []string{
+	"server_version", "max_connections", "shared_buffers", "work_mem", "fsync", "synchronous_commit", "wal_level",
+	"default_transaction_isolation", "deadlock_timeout", "lock_timeout", "statement_timeout",
+	"idle_in_transaction_session_timeout", "max_locks_per_transaction",
+}
+
+// ServerSettings reads the server settings recorded with a run, the settings set by the session or the client,
+// e.g. the session settings of the run, are left out.
+func ServerSettings(ctx context.Context, q *store.Queries) (store.GetServerSettingsRow, error) {
+	settings, err := q.GetServerSettings(ctx, serverSettingNames)
+	if err != nil {
+		return store.GetServerSettingsRow{}, fmt.Errorf("error reading the server settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// RunParams returns the experiment_run row of the run report.
+func RunParams(run *report.Run, gitSHA string, settings store.GetServerSettingsRow) store.InsertExperimentRunParams {
+	c := run.Config
+	return store.InsertExperimentRunParams{
+		RunID:              run.RunID,
+		GitSha:             gitSHA,
+		TripID:             run.TripID,
+		StartedAt:          run.StartedAt.UTC(),
+		ElapsedUs:          run.Elapsed.Microseconds(),
+		IsolationLevel:     c.IsolationLevel,
+		LockStrategy:       c.LockStrategy,
+		ClaimMode:          c.ClaimMode,
+		PoolSize:           int32(c.PoolSize),
+		PoolPolicy:         c.PoolPolicy,
+		MaxRetries:         int32(c.MaxRetries),
+		SavepointRetries:   int32(c.SavepointRetries),
+		QueryExecMode:      c.QueryExecMode,
+		CabinLayout:        c.CabinLayout,
+		SessionSettings:    c.SessionSettings,
+		ServerSettings:     settings.Settings,
+		ServerSettingsHash: settings.SettingsHash,
+		Passengers:         int32(run.Outcomes.Passengers),
+		Bookings:           int32(run.Outcomes.Bookings),
+		Attempts:           int32(run.Outcomes.Attempts),
+		FailedAttempts:     int32(run.Outcomes.FailedAttempts),
+		Throughput:         run.Throughput,
+		BookingP50Us:       run.Latency.Booking.P50.Microseconds(),
+		BookingP90Us:       run.Latency.Booking.P90.Microseconds(),
+		BookingP99Us:       run.Latency.Booking.P99.Microseconds(),
+		BookingMaxUs:       run.Latency.Booking.Max.Microseconds(),
+	}
+}
+
+// Record inserts the run and its attempts in a single transaction, so a run is never recorded without its attempts.
+func Record(ctx context.Context, conn *pgx.Conn, run store.InsertExperimentRunParams, attempts []store.InsertExperimentAttemptsParams) error {
+	return pgtx.WithTx(ctx, conn, pgtx.Options{}, func(tx pgx.Tx) error {
+		q := store.New(tx)
+		if err := q.InsertExperimentRun(ctx, run); err != nil {
+			return fmt.Errorf("error inserting experiment run %s: %w", run.RunID, err)
+		}
+
+		if _, err := q.InsertExperimentAttempts(ctx, attempts); err != nil {
+			return fmt.Errorf("error inserting the attempts of experiment run %s: %w", run.RunID, err)
+		}
+
+		return nil
+	})
+}
+
+// GitSHA returns the commit the binary was built from, suffixed with -dirty if the tree had local changes.
+// The commit is read from the build info, and from git for go test and go run, which don't stamp it.
+// It returns an empty string if the commit is unknown.
+func GitSHA() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+
+		if revision != "" {
+			if modified == "true" {
+				revision += "-dirty"
+			}
+			return revision
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "describe", "--always", "--dirty", "--abbrev=40").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
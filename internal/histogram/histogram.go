@@ -0,0 +1,128 @@
+// Package histogram records durations into an HDR-style histogram: values are counted in buckets whose width grows with
+// the value, so percentiles are reported with a bounded relative error of about 3% whatever the range of the values.
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// subBucketBits is the number of bits of a value kept by its bucket, every power of 2 is split into 2^subBucketBits buckets.
+	subBucketBits = 5
+	subBuckets    = 1 << subBucketBits
+)
+
+// Histogram is a histogram of durations recorded with microsecond resolution. The zero value is an empty histogram.
+// It isn't safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// Record adds the duration to the histogram, negative durations are recorded as 0.
+func (h *Histogram) Record(d time.Duration) {
+	d = max(d, 0)
+
+	i := bucketIndex(d.Microseconds())
+	if i >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, i+1-len(h.counts))...)
+	}
+	h.counts[i]++
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.count++
+	h.sum += d
+}
+
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	return h.sum / time.Duration(h.count)
+}
+
+// Percentile returns the value below which p percent of the recorded values fall, e.g. Percentile(99) is the p99.
+// The value is the upper bound of its bucket, capped at the maximum recorded value.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	// The nearest rank of the value, 1 for the smallest one
+	rank := int64(math.Ceil(p / 100 * float64(h.count)))
+	rank = min(max(rank, 1), h.count)
+
+	var seen int64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			return min(time.Duration(bucketUpperBound(i))*time.Microsecond, h.max)
+		}
+	}
+
+	return h.max
+}
+
+// Summary is the percentiles of a histogram.
+type Summary struct {
+	Count int64         `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+func (h *Histogram) Summary() Summary {
+	return Summary{
+		Count: h.count,
+		Mean:  h.Mean(),
+		P50:   h.Percentile(50),
+		P90:   h.Percentile(90),
+		P99:   h.Percentile(99),
+		Max:   h.max,
+	}
+}
+
+// bucketIndex returns the index of the bucket of the value. Values below subBuckets have a bucket of their own,
+// larger values share a bucket with the values that only differ in the bits below their top subBucketBits+1 bits.
+func bucketIndex(v int64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+
+	shift := bits.Len64(uint64(v)) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// bucketUpperBound returns the largest value counted in the bucket.
+func bucketUpperBound(i int) int64 {
+	if i < subBuckets {
+		return int64(i)
+	}
+
+	shift := i/subBuckets - 1
+	sub := int64(i%subBuckets + subBuckets)
+	return (sub+1)<<shift - 1
+}
@@ -0,0 +1,86 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuckets(t *testing.T) {
+	for _, v := range []int64{0, 1, 31, 32, 63, 64, 65, 127, 128, 1000, 123456, 1 << 40} {
+		i := bucketIndex(v)
+		upper := bucketUpperBound(i)
+		if upper < v {
+			t.Errorf("bucketUpperBound(bucketIndex(%d)) = %d, want at least %d", v, upper, v)
+		}
+
+		// The bucket is at most 1/32 of its values wide
+		if float64(upper-v) > float64(v)/subBuckets {
+			t.Errorf("bucketUpperBound(bucketIndex(%d)) = %d, want within %.1f%%", v, upper, 100.0/subBuckets)
+		}
+
+		if i > 0 && bucketUpperBound(i-1) >= v {
+			t.Errorf("bucketUpperBound(bucketIndex(%d)-1) = %d, want less than %d", v, bucketUpperBound(i-1), v)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var h Histogram
+	if got := h.Percentile(99); got != 0 {
+		t.Errorf("Percentile(99) of an empty histogram = %v, want 0", got)
+	}
+
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 50, want: 500 * time.Millisecond},
+		{p: 90, want: 900 * time.Millisecond},
+		{p: 99, want: 990 * time.Millisecond},
+		{p: 100, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		got := h.Percentile(tt.p)
+		if got < tt.want || float64(got-tt.want) > float64(tt.want)/subBuckets {
+			t.Errorf("Percentile(%v) = %v, want %v within %.1f%%", tt.p, got, tt.want, 100.0/subBuckets)
+		}
+	}
+
+	if s := h.Summary(); s.Count != 1000 || s.Max != time.Second || s.Mean != 500500*time.Microsecond {
+		t.Errorf("Summary() = %+v, want 1000 values with max 1s and mean 500.5ms", s)
+	}
+}
+
+func TestPercentileSmallCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+		p       float64
+		want    time.Duration
+	}{
+		// The nearest rank is rounded up, 2 of 3 and 10 of 10
+		{name: "MedianOfThree", samples: 3, p: 50, want: 20 * time.Millisecond},
+		{name: "P99OfTen", samples: 10, p: 99, want: 100 * time.Millisecond},
+		{name: "P1OfTen", samples: 10, p: 1, want: 10 * time.Millisecond},
+		{name: "P0", samples: 10, p: 0, want: 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Histogram
+			for i := 1; i <= tt.samples; i++ {
+				h.Record(time.Duration(i) * 10 * time.Millisecond)
+			}
+
+			got := h.Percentile(tt.p)
+			if got < tt.want || float64(got-tt.want) > float64(tt.want)/subBuckets {
+				t.Errorf("Percentile(%v) of %d samples = %v, want %v within %.1f%%", tt.p, tt.samples, got, tt.want, 100.0/subBuckets)
+			}
+		})
+	}
+}
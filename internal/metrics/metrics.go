@@ -0,0 +1,208 @@
+// Package metrics keeps counters, gauges and histograms and writes them in the Prometheus text exposition format,
+// so long-running experiments can be scraped by a local Prometheus.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Labels are the names and values of the labels of a series.
+type Labels map[string]string
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets of a booking's latency.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type kind string
+
+const (
+	counterKind   kind = "counter"
+	gaugeKind     kind = "gauge"
+	histogramKind kind = "histogram"
+)
+
+// family is a metric with all its series.
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labels Labels
+	value  float64
+	// gauge reads the value of a gauge when it is scraped, if set.
+	gauge func() float64
+	// counts are the non-cumulative counts of the histogram buckets, the last one counts the values above all the buckets.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Registry holds the metrics exposed by Handler. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Add adds v to the counter with the labels.
+func (r *Registry) Add(name string, help string, labels Labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.series(name, help, counterKind, nil, labels).value += v
+}
+
+// Inc increments the counter with the labels.
+func (r *Registry) Inc(name string, help string, labels Labels) {
+	r.Add(name, help, labels, 1)
+}
+
+// GaugeFunc registers a gauge with the labels, whose value is read by fn when it is scraped. fn replaces any previous
+// function of the gauge, and must be safe to call concurrently.
+func (r *Registry) GaugeFunc(name string, help string, labels Labels, fn func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.series(name, help, gaugeKind, nil, labels).gauge = fn
+}
+
+// Observe records v in the histogram with the labels, the histogram is created with buckets on first use.
+func (r *Registry) Observe(name string, help string, buckets []float64, labels Labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.series(name, help, histogramKind, buckets, labels)
+	f := r.families[name]
+
+	i := sort.SearchFloat64s(f.buckets, v)
+	s.counts[i]++
+	s.sum += v
+	s.count++
+}
+
+func (r *Registry) series(name string, help string, k kind, buckets []float64, labels Labels) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: k, buckets: buckets, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	key := labelString(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		if k == histogramKind {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			switch f.kind {
+			case histogramKind:
+				var cumulative uint64
+				for i, upper := range f.buckets {
+					cumulative += s.counts[i]
+					fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, labelString(s.labels, "le", formatFloat(upper)), cumulative)
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, labelString(s.labels, "le", "+Inf"), s.count)
+				fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, key, formatFloat(s.sum))
+				fmt.Fprintf(&b, "%s_count%s %d\n", f.name, key, s.count)
+			default:
+				value := s.value
+				if s.gauge != nil {
+					value = s.gauge()
+				}
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, key, formatFloat(value))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// labelString renders the labels, followed by the extra name/value pairs, as {name="value",...} in the order of their names.
+// %q escapes backslashes, double quotes and newlines the way the exposition format expects.
+func labelString(labels Labels, extra ...string) string {
+	pairs := make([]string, 0, len(labels)+len(extra)/2)
+	for name, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(pairs)
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
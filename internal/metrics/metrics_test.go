@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	labels := Labels{"lock_strategy": "GetSeatWithExclusiveLock", "pool_size": "5"}
+
+	r.Inc("booking_attempts_total", "Booking attempts.", labels)
+	r.Inc("booking_attempts_total", "Booking attempts.", labels)
+	r.Inc("booking_failures_total", "Failed booking attempts.", Labels{"sqlstate": "40P01"})
+	r.GaugeFunc("booking_pool_waiters", "Waiters.", labels, func() float64 { return 3 })
+	r.Observe("booking_latency_seconds", "Latency.", []float64{0.1, 1}, labels, 0.05)
+	r.Observe("booking_latency_seconds", "Latency.", []float64{0.1, 1}, labels, 0.5)
+	r.Observe("booking_latency_seconds", "Latency.", []float64{0.1, 1}, labels, 5)
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	want := `# HELP booking_attempts_total Booking attempts.
+# TYPE booking_attempts_total counter
+booking_attempts_total{lock_strategy="GetSeatWithExclusiveLock",pool_size="5"} 2
+# HELP booking_failures_total Failed booking attempts.
+# TYPE booking_failures_total counter
+booking_failures_total{sqlstate="40P01"} 1
+# HELP booking_latency_seconds Latency.
+# TYPE booking_latency_seconds histogram
+booking_latency_seconds_bucket{lock_strategy="GetSeatWithExclusiveLock",pool_size="5",le="0.1"} 1
+booking_latency_seconds_bucket{lock_strategy="GetSeatWithExclusiveLock",pool_size="5",le="1"} 2
+booking_latency_seconds_bucket{lock_strategy="GetSeatWithExclusiveLock",pool_size="5",le="+Inf"} 3
+booking_latency_seconds_sum{lock_strategy="GetSeatWithExclusiveLock",pool_size="5"} 5.55
+booking_latency_seconds_count{lock_strategy="GetSeatWithExclusiveLock",pool_size="5"} 3
+# HELP booking_pool_waiters Waiters.
+# TYPE booking_pool_waiters gauge
+booking_pool_waiters{lock_strategy="GetSeatWithExclusiveLock",pool_size="5"} 3
+`
+	if out.String() != want {
+		t.Errorf("WriteText() = \n%s\nwant\n%s", out.String(), want)
+	}
+}
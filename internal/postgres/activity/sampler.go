@@ -0,0 +1,162 @@
+package postgresactivity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Backend is the state of a tagged Postgres backend at the time it was sampled.
+type Backend struct {
+	PID           int32
+	Tag           Tag
+	State         string
+	WaitEventType string
+	WaitEvent     string
+	// XactAge is the time since the backend's current transaction started, 0 outside a transaction.
+	XactAge time.Duration
+	Query   string
+}
+
+// Waiting reports whether the backend was waiting on anything other than the client when it was sampled.
+func (b Backend) Waiting() bool {
+	return b.WaitEventType != "" && b.WaitEventType != "Client" && b.WaitEventType != "Activity"
+}
+
+// Sample is the state of the backends of a run at a point in time.
+type Sample struct {
+	At       time.Time
+	Backends []Backend
+}
+
+// Sampler periodically samples pg_stat_activity for the backends tagged with a run ID, on a connection of its own.
+type Sampler struct {
+	q        *store.Queries
+	runID    string
+	interval time.Duration
+
+	mu      sync.Mutex
+	samples []Sample
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func NewSampler(conn *pgx.Conn, runID string, interval time.Duration) *Sampler {
+	return &Sampler{
+		q:        store.New(conn),
+		runID:    runID,
+		interval: interval,
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start samples the backends every interval until Stop is called or ctx is done.
+func (s *Sampler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(s.stopped)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.sample(ctx); err != nil && ctx.Err() == nil {
+					logrus.WithError(err).Warn("error sampling backend activity")
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops sampling and returns the samples taken.
+func (s *Sampler) Stop() []Sample {
+	s.cancel()
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.samples
+}
+
+func (s *Sampler) sample(ctx context.Context) error {
+	rows, err := s.q.GetBackendActivity(ctx, RunPattern(s.runID))
+	if err != nil {
+		return err
+	}
+
+	sample := Sample{At: time.Now(), Backends: make([]Backend, 0, len(rows))}
+	for _, row := range rows {
+		tag, ok := ParseTag(row.ApplicationName)
+		if !ok {
+			continue
+		}
+
+		sample.Backends = append(sample.Backends, Backend{
+			PID:           row.Pid,
+			Tag:           tag,
+			State:         row.State,
+			WaitEventType: row.WaitEventType,
+			WaitEvent:     row.WaitEvent,
+			XactAge:       time.Duration(row.XactAgeMs * float64(time.Millisecond)),
+			Query:         row.Query,
+		})
+	}
+
+	s.mu.Lock()
+	s.samples = append(s.samples, sample)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// LastSeen returns the last sampled state of the backend while it worked on each passenger's booking, keyed by passenger ID.
+// A connection keeps its booking tag after it is released, so the samples of the idle backend are skipped.
+func LastSeen(samples []Sample) map[int32]Backend {
+	lastSeen := make(map[int32]Backend)
+	for _, sample := range samples {
+		for _, backend := range sample.Backends {
+			if backend.Tag.PassengerID > 0 && backend.State != "idle" {
+				lastSeen[backend.Tag.PassengerID] = backend
+			}
+		}
+	}
+
+	return lastSeen
+}
+
+// WaitForExit waits until none of the backends tagged with the run ID are left in pg_stat_activity, e.g. after closing
+// the connections of the run, so the statistics they flush on exit are visible. It gives up once the timeout expires.
+func WaitForExit(ctx context.Context, conn *pgx.Conn, runID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	q := store.New(conn)
+	for {
+		backends, err := q.GetBackendActivity(ctx, RunPattern(runID))
+		if err != nil {
+			return fmt.Errorf("error waiting for the backends of run %s to exit: %w", runID, err)
+		}
+
+		if len(backends) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d backends of run %s still running: %w", len(backends), runID, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
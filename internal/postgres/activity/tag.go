@@ -0,0 +1,119 @@
+package postgresactivity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	tagPrefix = "arp"
+	// Postgres truncates application_name to 63 bytes
+	maxApplicationNameLen = 63
+)
+
+// Tag identifies the run, the pool slot and the booking a backend is working on. It is encoded in the
+// application_name of the connection as "arp:<run>:s<slot>:p<passenger>:a<attempt>", where the booking part
+// is only present while the connection is used for a booking.
+type Tag struct {
+	RunID       string `json:"run_id"`
+	Slot        int    `json:"slot"`
+	PassengerID int32  `json:"passenger_id,omitempty"`
+	Attempt     int    `json:"attempt,omitempty"`
+}
+
+// RunApplicationName returns the application_name prefix shared by all the connections of a run.
+func RunApplicationName(runID string) string {
+	return tagPrefix + ":" + runID
+}
+
+// SlotApplicationName returns the application_name of the connection in the given pool slot of a run.
+func SlotApplicationName(runApplicationName string, slot int) string {
+	return fmt.Sprintf("%s:s%d", runApplicationName, slot)
+}
+
+// RunPattern returns the LIKE pattern matching the application_name of every connection of a run.
+func RunPattern(runID string) string {
+	return RunApplicationName(runID) + ":%"
+}
+
+// String returns the application_name encoding the tag.
+func (t Tag) String() string {
+	name := SlotApplicationName(RunApplicationName(t.RunID), t.Slot)
+	if t.PassengerID > 0 {
+		name += fmt.Sprintf(":p%d:a%d", t.PassengerID, t.Attempt)
+	}
+
+	if len(name) > maxApplicationNameLen {
+		name = name[:maxApplicationNameLen]
+	}
+
+	return name
+}
+
+// ParseTag decodes the tag from an application_name, it reports false if the name isn't a slot or booking tag.
+func ParseTag(applicationName string) (Tag, bool) {
+	parts := strings.Split(applicationName, ":")
+	if (len(parts) != 3 && len(parts) != 5) || parts[0] != tagPrefix {
+		return Tag{}, false
+	}
+
+	t := Tag{RunID: parts[1]}
+
+	slot, ok := parseField(parts[2], 's')
+	if !ok {
+		return Tag{}, false
+	}
+	t.Slot = int(slot)
+
+	if len(parts) == 5 {
+		passengerID, ok := parseField(parts[3], 'p')
+		if !ok {
+			return Tag{}, false
+		}
+
+		attempt, ok := parseField(parts[4], 'a')
+		if !ok {
+			return Tag{}, false
+		}
+
+		t.PassengerID = int32(passengerID)
+		t.Attempt = int(attempt)
+	}
+
+	return t, true
+}
+
+func parseField(field string, prefix byte) (int64, bool) {
+	if len(field) < 2 || field[0] != prefix {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(field[1:], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// TagBooking sets the application_name of conn to the tag of its pool slot extended with the passenger and attempt,
+// so the backend can be matched to the booking in pg_stat_activity. Connections that aren't tagged with a slot are
+// left alone. The tag is left in place when the connection is released, until the next booking replaces it.
+func TagBooking(ctx context.Context, conn *pgx.Conn, passengerID int32, attempt int) error {
+	tag, ok := ParseTag(conn.Config().RuntimeParams["application_name"])
+	if !ok {
+		return nil
+	}
+
+	tag.PassengerID = passengerID
+	tag.Attempt = attempt
+	if _, err := conn.Exec(ctx, "SELECT set_config('application_name', $1, false)", tag.String()); err != nil {
+		return fmt.Errorf("error setting application_name to %s: %w", tag, err)
+	}
+
+	return nil
+}
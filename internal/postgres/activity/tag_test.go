@@ -0,0 +1,34 @@
+package postgresactivity
+
+import (
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		applicationName string
+		want            Tag
+		wantOK          bool
+	}{
+		{applicationName: "arp:1f2e3d4c:s7", want: Tag{RunID: "1f2e3d4c", Slot: 7}, wantOK: true},
+		{applicationName: "arp:1f2e3d4c:s7:p42:a2", want: Tag{RunID: "1f2e3d4c", Slot: 7, PassengerID: 42, Attempt: 2}, wantOK: true},
+		{applicationName: "arp:1f2e3d4c"},
+		{applicationName: "arp:1f2e3d4c:7"},
+		{applicationName: "arp:1f2e3d4c:s7:p42"},
+		{applicationName: "arp:1f2e3d4c:s7:a2:p42"},
+		{applicationName: "psql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.applicationName, func(t *testing.T) {
+			got, ok := ParseTag(tt.applicationName)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("ParseTag(%q) = %+v, %v, want %+v, %v", tt.applicationName, got, ok, tt.want, tt.wantOK)
+			}
+
+			if ok && got.String() != tt.applicationName {
+				t.Errorf("ParseTag(%q).String() = %q", tt.applicationName, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package postgresactivity
+
+import (
+	"sort"
+)
+
+// OnCPU is the wait event of an active backend that isn't waiting on anything, i.e. running or ready to run.
+const OnCPU = "CPU"
+
+// WaitEventCount is the number of times backends were sampled in a wait event, e.g. Lock:transactionid.
+type WaitEventCount struct {
+	WaitEvent string  `json:"wait_event"`
+	Samples   int     `json:"samples"`
+	Share     float64 `json:"share"`
+}
+
+// WaitEventName returns the wait event of the backend as "<wait_event_type>:<wait_event>", OnCPU if an active backend isn't waiting,
+// and the state of the backend otherwise.
+func (b Backend) WaitEventName() string {
+	switch {
+	case b.WaitEventType != "":
+		return b.WaitEventType + ":" + b.WaitEvent
+	case b.State == "active":
+		return OnCPU
+	default:
+		return b.State
+	}
+}
+
+// WaitEventHistogram counts the samples of every wait event over all the backends of all the samples,
+// ordered from the most to the least sampled wait event.
+func WaitEventHistogram(samples []Sample) []WaitEventCount {
+	counts := make(map[string]int)
+	total := 0
+	for _, sample := range samples {
+		for _, backend := range sample.Backends {
+			counts[backend.WaitEventName()]++
+			total++
+		}
+	}
+
+	histogram := make([]WaitEventCount, 0, len(counts))
+	for waitEvent, n := range counts {
+		histogram = append(histogram, WaitEventCount{WaitEvent: waitEvent, Samples: n, Share: float64(n) / float64(total)})
+	}
+
+	sort.Slice(histogram, func(i, j int) bool {
+		if histogram[i].Samples != histogram[j].Samples {
+			return histogram[i].Samples > histogram[j].Samples
+		}
+
+		return histogram[i].WaitEvent < histogram[j].WaitEvent
+	})
+
+	return histogram
+}
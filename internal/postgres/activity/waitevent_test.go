@@ -0,0 +1,41 @@
+package postgresactivity
+
+import (
+	"testing"
+)
+
+func TestWaitEventHistogram(t *testing.T) {
+	samples := []Sample{
+		{Backends: []Backend{
+			{State: "active", WaitEventType: "Lock", WaitEvent: "transactionid"},
+			{State: "active", WaitEventType: "Lock", WaitEvent: "transactionid"},
+			{State: "active"},
+			{State: "idle", WaitEventType: "Client", WaitEvent: "ClientRead"},
+		}},
+		{Backends: []Backend{
+			{State: "active", WaitEventType: "Lock", WaitEvent: "tuple"},
+			{State: "active", WaitEventType: "Lock", WaitEvent: "transactionid"},
+			{State: "idle in transaction"},
+			{State: "active"},
+		}},
+	}
+
+	want := []WaitEventCount{
+		{WaitEvent: "Lock:transactionid", Samples: 3, Share: 0.375},
+		{WaitEvent: OnCPU, Samples: 2, Share: 0.25},
+		{WaitEvent: "Client:ClientRead", Samples: 1, Share: 0.125},
+		{WaitEvent: "Lock:tuple", Samples: 1, Share: 0.125},
+		{WaitEvent: "idle in transaction", Samples: 1, Share: 0.125},
+	}
+
+	got := WaitEventHistogram(samples)
+	if len(got) != len(want) {
+		t.Fatalf("WaitEventHistogram() = %+v, want %+v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("WaitEventHistogram()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
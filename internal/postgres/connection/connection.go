@@ -2,7 +2,9 @@ package postgresconnection
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -14,11 +16,21 @@ type Config struct {
 	Username string
 	Password string
 	Database string
+	// SessionSettings are applied to every new connection.
+	SessionSettings SessionSettings
+	// QueryExecMode is the protocol pgx uses to execute queries, the zero value keeps the pgx default of caching prepared statements.
+	QueryExecMode pgx.QueryExecMode
+	// StatementCacheCapacity is the size of the prepared statement or description cache, 0 keeps the pgx default.
+	StatementCacheCapacity int
+	// ApplicationName is reported in pg_stat_activity for the connection, empty keeps the server default.
+	ApplicationName string
+	// Tracer traces the queries sent on the connection, nil doesn't trace them.
+	Tracer pgx.QueryTracer
 }
 
 // NewConnection returns a new connection instance to connect to the Postgres database.
 func NewConnection(config *Config) (*pgx.Conn, error) {
-	connConfig, err := pgx.ParseConfig(fmt.Sprintf("postgres://%s:%s@%s:%d/%s", config.Username, config.Password, config.Host, config.Port, config.Database))
+	connConfig, err := parseConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -29,9 +41,51 @@ func NewConnection(config *Config) (*pgx.Conn, error) {
 		return nil, err
 	}
 
+	if err := ApplySessionSettings(context.Background(), conn, config.SessionSettings); err != nil {
+		return nil, errors.Join(err, conn.Close(context.Background()))
+	}
+
 	return conn, nil
 }
 
+// parseConfig returns the pgx configuration of the connection.
+func parseConfig(config *Config) (*pgx.ConnConfig, error) {
+	connConfig, err := pgx.ParseConfig(fmt.Sprintf("postgres://%s:%s@%s:%d/%s", config.Username, config.Password, config.Host, config.Port, config.Database))
+	if err != nil {
+		return nil, err
+	}
+
+	// Count the round trips made over the connection, see RoundTrips
+	dial := connConfig.DialFunc
+	connConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &roundTripConn{Conn: conn}, nil
+	}
+
+	if config.ApplicationName != "" {
+		connConfig.RuntimeParams["application_name"] = config.ApplicationName
+	}
+
+	if config.Tracer != nil {
+		connConfig.Tracer = config.Tracer
+	}
+
+	// pgx rejects the zero mode, so leave the default of ParseConfig in place unless a mode is set
+	if config.QueryExecMode != 0 {
+		connConfig.DefaultQueryExecMode = config.QueryExecMode
+	}
+	if config.StatementCacheCapacity > 0 {
+		connConfig.StatementCacheCapacity = config.StatementCacheCapacity
+		connConfig.DescriptionCacheCapacity = config.StatementCacheCapacity
+	}
+
+	return connConfig, nil
+}
+
 // Close closes the connection to the Postgres database.
 func Close(conn *pgx.Conn) error {
 	return conn.Close(context.Background())
@@ -0,0 +1,31 @@
+package postgresconnection
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestParseConfigQueryExecMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode pgx.QueryExecMode
+		want pgx.QueryExecMode
+	}{
+		{name: "Unset", want: pgx.QueryExecModeCacheStatement},
+		{name: "SimpleProtocol", mode: pgx.QueryExecModeSimpleProtocol, want: pgx.QueryExecModeSimpleProtocol},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connConfig, err := parseConfig(&Config{Host: "localhost", Port: 5432, Username: "postgres", Password: "postgres", Database: "db", QueryExecMode: tt.mode})
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+
+			if connConfig.DefaultQueryExecMode != tt.want {
+				t.Errorf("DefaultQueryExecMode = %s, want %s", connConfig.DefaultQueryExecMode, tt.want)
+			}
+		})
+	}
+}
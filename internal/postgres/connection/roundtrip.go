@@ -0,0 +1,51 @@
+package postgresconnection
+
+import (
+	"crypto/tls"
+	"net"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// roundTripConn counts the round trips made over a connection to the server. A round trip is counted
+// whenever the client reads from the server after having written to it.
+type roundTripConn struct {
+	net.Conn
+	wrote      atomic.Bool
+	roundTrips atomic.Int64
+}
+
+func (c *roundTripConn) Write(b []byte) (int, error) {
+	c.wrote.Store(true)
+	return c.Conn.Write(b)
+}
+
+func (c *roundTripConn) Read(b []byte) (int, error) {
+	if c.wrote.Swap(false) {
+		c.roundTrips.Add(1)
+	}
+
+	return c.Conn.Read(b)
+}
+
+// RoundTrips returns the number of round trips made over conn since it was opened, including the TLS handshake
+// if the connection was upgraded to TLS. It returns 0 if conn wasn't opened by NewConnection.
+func RoundTrips(conn *pgx.Conn) int64 {
+	if c := roundTripCounter(conn.PgConn().Conn()); c != nil {
+		return c.roundTrips.Load()
+	}
+
+	return 0
+}
+
+// roundTripCounter returns the roundTripConn under the connection, pgconn wraps the dialed connection
+// in a *tls.Conn when it's upgraded to TLS, e.g. with the default sslmode=prefer.
+func roundTripCounter(conn net.Conn) *roundTripConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	c, _ := conn.(*roundTripConn)
+	return c
+}
@@ -0,0 +1,34 @@
+package postgresconnection
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+)
+
+func TestRoundTripCounter(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	counted := &roundTripConn{Conn: client}
+	tests := []struct {
+		name string
+		conn net.Conn
+	}{
+		{name: "Plain", conn: counted},
+		{name: "TLS", conn: tls.Client(counted, &tls.Config{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundTripCounter(tt.conn); got != counted {
+				t.Errorf("roundTripCounter() = %p, want the counting connection %p", got, counted)
+			}
+		})
+	}
+
+	if got := roundTripCounter(client); got != nil {
+		t.Errorf("roundTripCounter() of a connection that isn't counted = %p, want nil", got)
+	}
+}
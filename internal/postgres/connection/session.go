@@ -0,0 +1,85 @@
+package postgresconnection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	insufficientPrivilege = "42501"
+)
+
+// SessionSettings are session level settings applied to every connection when it is opened,
+// so they can be varied per run without restarting the database. A zero value keeps the server default.
+type SessionSettings struct {
+	LockTimeout      time.Duration
+	StatementTimeout time.Duration
+	// DeadlockTimeout can only be changed by a superuser, it is skipped with a warning otherwise.
+	DeadlockTimeout                 time.Duration
+	IdleInTransactionSessionTimeout time.Duration
+}
+
+type sessionSetting struct {
+	name  string
+	value time.Duration
+}
+
+// milliseconds returns the value in the whole milliseconds Postgres expects, rounded up,
+// as a timeout below 1ms would otherwise be sent as 0 and disable the timeout on the server.
+func (s sessionSetting) milliseconds() string {
+	return fmt.Sprintf("%dms", (s.value+time.Millisecond-1)/time.Millisecond)
+}
+
+// settings returns the settings in the order they are applied, paired with their Postgres parameter names.
+func (s SessionSettings) settings() []sessionSetting {
+	return []sessionSetting{
+		{name: "lock_timeout", value: s.LockTimeout},
+		{name: "statement_timeout", value: s.StatementTimeout},
+		{name: "deadlock_timeout", value: s.DeadlockTimeout},
+		{name: "idle_in_transaction_session_timeout", value: s.IdleInTransactionSessionTimeout},
+	}
+}
+
+// String returns the settings in "name=value" form, settings left to the server default are shown as "default".
+func (s SessionSettings) String() string {
+	settings := make([]string, 0, 4)
+	for _, setting := range s.settings() {
+		value := "default"
+		if setting.value > 0 {
+			value = setting.value.String()
+		}
+
+		settings = append(settings, fmt.Sprintf("%s=%s", setting.name, value))
+	}
+
+	return strings.Join(settings, " ")
+}
+
+// ApplySessionSettings sets the non-zero session settings on conn.
+func ApplySessionSettings(ctx context.Context, conn *pgx.Conn, s SessionSettings) error {
+	for _, setting := range s.settings() {
+		if setting.value <= 0 {
+			continue
+		}
+
+		_, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", setting.name, setting.milliseconds())
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == insufficientPrivilege {
+				logrus.WithError(err).Warnf("not permitted to set %s, keeping the server default", setting.name)
+				continue
+			}
+
+			return fmt.Errorf("error setting %s: %w", setting.name, err)
+		}
+	}
+
+	return nil
+}
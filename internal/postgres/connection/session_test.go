@@ -0,0 +1,25 @@
+package postgresconnection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionSettingMilliseconds(t *testing.T) {
+	tests := []struct {
+		value time.Duration
+		want  string
+	}{
+		{value: time.Microsecond, want: "1ms"},
+		{value: time.Millisecond, want: "1ms"},
+		{value: 1500 * time.Microsecond, want: "2ms"},
+		{value: 2 * time.Second, want: "2000ms"},
+	}
+
+	for _, tt := range tests {
+		setting := sessionSetting{name: "lock_timeout", value: tt.value}
+		if got := setting.milliseconds(); got != tt.want {
+			t.Errorf("milliseconds() of %v = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
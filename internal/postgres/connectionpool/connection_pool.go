@@ -1,36 +1,80 @@
 package postgresconnectionpool
 
 import (
+	"context"
+	"errors"
+	"math/rand"
 	"sync"
 
 	"github.com/jackc/pgx/v5"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
 	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
 )
 
+// AcquisitionPolicy decides which waiting goroutine is handed a connection when one is released.
+type AcquisitionPolicy string
+
+const (
+	// Unordered hands a released connection to an arbitrary waiter, the same as goroutines racing on a channel receive.
+	Unordered AcquisitionPolicy = "UNORDERED"
+	// FIFO hands a released connection to the waiter that has been waiting the longest.
+	FIFO AcquisitionPolicy = "FIFO"
+	// LIFO hands a released connection to the waiter that arrived most recently.
+	LIFO AcquisitionPolicy = "LIFO"
+	// PriorityFIFO hands a released connection to the waiter with the highest priority, ties are broken in arrival order.
+	PriorityFIFO AcquisitionPolicy = "PRIORITY"
+)
+
+// Priority is the priority class of an acquire request, higher values are served first under PriorityFIFO.
+type Priority int
+
+// ErrClosed is returned by Acquire once the pool is closed.
+var ErrClosed = errors.New("connection pool is closed")
+
+type waiter struct {
+	priority Priority
+	// arrival is the position of the acquire request in the order of arrival at the pool.
+	arrival int64
+	ready   chan *pgx.Conn
+}
+
 type ConnectionPool struct {
 	mu      *sync.Mutex
 	conns   []*pgx.Conn
 	maxConn int
-	channel chan interface{}
+	policy  AcquisitionPolicy
+	// waiters is kept in arrival order, the policy decides which one is served next.
+	waiters []*waiter
+	// arrivals is the number of acquire requests that arrived at the pool.
+	arrivals int64
+	// handedOver are the arrival positions of the acquire requests, in the order they were handed a connection.
+	handedOver []int64
+	// closed is set by Close, connections released after it are closed instead of being pooled.
+	closed bool
 }
 
-func NewConnectionPool(config *pgconn.Config, maxConn int) (*ConnectionPool, error) {
+func NewConnectionPool(config *pgconn.Config, maxConn int, policy AcquisitionPolicy) (*ConnectionPool, error) {
 	var mu = sync.Mutex{}
 	cPool := &ConnectionPool{
 		mu:      &mu,
 		conns:   make([]*pgx.Conn, 0, maxConn),
 		maxConn: maxConn,
-		channel: make(chan interface{}, maxConn),
+		policy:  policy,
 	}
 
 	for i := 0; i < maxConn; i++ {
-		conn, err := pgconn.NewConnection(config)
+		// Tell the connections apart in pg_stat_activity by the slot they were opened for
+		slotConfig := *config
+		if config.ApplicationName != "" {
+			slotConfig.ApplicationName = pgactivity.SlotApplicationName(config.ApplicationName, i)
+		}
+
+		conn, err := pgconn.NewConnection(&slotConfig)
 		if err != nil {
 			return nil, err
 		}
 
 		cPool.conns = append(cPool.conns, conn)
-		cPool.channel <- struct{}{}
 	}
 
 	return cPool, nil
@@ -40,31 +84,148 @@ func (cPool *ConnectionPool) NumberOfConnections() int {
 	return len(cPool.conns)
 }
 
-func (cpool *ConnectionPool) Acquire() *pgx.Conn {
-	<-cpool.channel
+// Stats is a point in time view of how the connections of a pool are used.
+type Stats struct {
+	Size    int
+	Idle    int
+	InUse   int
+	Waiters int
+}
 
-	cpool.mu.Lock()
-	c := cpool.conns[0]
-	cpool.conns = cpool.conns[1:]
-	cpool.mu.Unlock()
+// Stats returns how many of the pool's connections are idle and in use, and how many callers are waiting for one.
+func (cPool *ConnectionPool) Stats() Stats {
+	cPool.mu.Lock()
+	defer cPool.mu.Unlock()
 
-	return c
+	return Stats{
+		Size:    cPool.maxConn,
+		Idle:    len(cPool.conns),
+		InUse:   cPool.maxConn - len(cPool.conns),
+		Waiters: len(cPool.waiters),
+	}
 }
 
-func (cPool *ConnectionPool) Release(c *pgx.Conn) {
+// Acquire returns an idle connection, or blocks until one is released and the pool's policy picks this caller.
+// It returns the context error if the context is done before a connection is handed over.
+func (cPool *ConnectionPool) Acquire(ctx context.Context, priority Priority) (*pgx.Conn, error) {
 	cPool.mu.Lock()
-	cPool.conns = append(cPool.conns, c)
-	cPool.channel <- struct{}{}
+	if cPool.closed {
+		cPool.mu.Unlock()
+		return nil, ErrClosed
+	}
+
+	cPool.arrivals++
+	if len(cPool.conns) > 0 && len(cPool.waiters) == 0 {
+		c := cPool.conns[0]
+		cPool.conns = cPool.conns[1:]
+		cPool.handedOver = append(cPool.handedOver, cPool.arrivals)
+		cPool.mu.Unlock()
+
+		return c, nil
+	}
+
+	w := &waiter{priority: priority, arrival: cPool.arrivals, ready: make(chan *pgx.Conn, 1)}
+	cPool.waiters = append(cPool.waiters, w)
 	cPool.mu.Unlock()
+
+	select {
+	case c := <-w.ready:
+		return c, nil
+	case <-ctx.Done():
+		cPool.mu.Lock()
+		removed := cPool.removeWaiter(w)
+		cPool.mu.Unlock()
+
+		if !removed {
+			// A connection was handed over while the context was being cancelled, put it back into the pool.
+			cPool.Release(<-w.ready)
+		}
+
+		return nil, ctx.Err()
+	}
 }
 
-func (cPool *ConnectionPool) Close() error {
-	for _, conn := range cPool.conns {
-		err := pgconn.Close(conn)
-		if err != nil {
-			return err
+func (cPool *ConnectionPool) Release(c *pgx.Conn) {
+	cPool.mu.Lock()
+	defer cPool.mu.Unlock()
+
+	if cPool.closed {
+		// The connection was checked out when the pool was closed
+		_ = pgconn.Close(c)
+		return
+	}
+
+	if len(cPool.waiters) == 0 {
+		cPool.conns = append(cPool.conns, c)
+		return
+	}
+
+	i := cPool.nextWaiter()
+	w := cPool.waiters[i]
+	cPool.waiters = append(cPool.waiters[:i], cPool.waiters[i+1:]...)
+	cPool.handedOver = append(cPool.handedOver, w.arrival)
+	w.ready <- c
+}
+
+// AcquisitionOrder returns the arrival positions of the acquire requests, starting at 1,
+// in the order they were handed a connection.
+func (cPool *ConnectionPool) AcquisitionOrder() []int64 {
+	cPool.mu.Lock()
+	defer cPool.mu.Unlock()
+
+	order := make([]int64, len(cPool.handedOver))
+	copy(order, cPool.handedOver)
+
+	return order
+}
+
+// nextWaiter returns the index of the waiter to be served next, the caller must hold the lock.
+func (cPool *ConnectionPool) nextWaiter() int {
+	switch cPool.policy {
+	case FIFO:
+		return 0
+	case LIFO:
+		return len(cPool.waiters) - 1
+	case PriorityFIFO:
+		next := 0
+		for i, w := range cPool.waiters {
+			if w.priority > cPool.waiters[next].priority {
+				next = i
+			}
 		}
+
+		return next
+	default:
+		return rand.Intn(len(cPool.waiters))
+	}
+}
+
+// removeWaiter removes w from the wait queue and reports whether it was still waiting, the caller must hold the lock.
+func (cPool *ConnectionPool) removeWaiter(w *waiter) bool {
+	for i, candidate := range cPool.waiters {
+		if candidate == w {
+			cPool.waiters = append(cPool.waiters[:i], cPool.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Close closes the idle connections, the connections still in use are closed as they are released.
+// Closing a closed pool does nothing.
+func (cPool *ConnectionPool) Close() error {
+	cPool.mu.Lock()
+	defer cPool.mu.Unlock()
+
+	cPool.closed = true
+	conns := cPool.conns
+	cPool.conns = nil
+
+	var errs []error
+	for _, conn := range conns {
+		errs = append(errs, pgconn.Close(conn))
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,100 @@
+package postgresconnectionpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestAcquisitionPolicies(t *testing.T) {
+	// Waiters arrive in this order with these priorities, the connection is then released once per waiter.
+	priorities := []Priority{0, 1, 0, 2, 1}
+
+	tests := []struct {
+		policy AcquisitionPolicy
+		want   []int
+	}{
+		{policy: FIFO, want: []int{0, 1, 2, 3, 4}},
+		{policy: LIFO, want: []int{4, 3, 2, 1, 0}},
+		{policy: PriorityFIFO, want: []int{3, 1, 4, 0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			conn := &pgx.Conn{}
+			cPool := &ConnectionPool{mu: &sync.Mutex{}, maxConn: 1, policy: tt.policy}
+
+			served := make(chan int, len(priorities))
+			for i, priority := range priorities {
+				go func() {
+					c, err := cPool.Acquire(context.Background(), priority)
+					if err != nil {
+						t.Errorf("waiter %d: unexpected error: %v", i, err)
+						return
+					}
+
+					served <- i
+					cPool.Release(c)
+				}()
+
+				waitForWaiters(t, cPool, i+1)
+			}
+
+			// Hand over the only connection, every waiter passes it on to the next one when done.
+			cPool.Release(conn)
+
+			for _, want := range tt.want {
+				if got := <-served; got != want {
+					t.Fatalf("served waiter %d, want %d", got, want)
+				}
+			}
+
+			// The first waiter's acquire arrived first, and the arrival positions start at 1
+			order := cPool.AcquisitionOrder()
+			for i, want := range tt.want {
+				if order[i] != int64(want+1) {
+					t.Fatalf("AcquisitionOrder() = %v, want the arrival positions of %v", order, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAcquireCancelled(t *testing.T) {
+	cPool := &ConnectionPool{mu: &sync.Mutex{}, maxConn: 1, policy: FIFO}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := cPool.Acquire(ctx, 0); err == nil {
+		t.Fatal("expected an error when the context is done before a connection is released")
+	}
+
+	cPool.Release(&pgx.Conn{})
+	if got := cPool.NumberOfConnections(); got != 1 {
+		t.Fatalf("idle connections = %d, want 1", got)
+	}
+}
+
+// waitForWaiters blocks until n goroutines are queued in the pool, so they arrive in a known order.
+func waitForWaiters(t *testing.T, cPool *ConnectionPool, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		cPool.mu.Lock()
+		waiting := len(cPool.waiters)
+		cPool.mu.Unlock()
+
+		if waiting == n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
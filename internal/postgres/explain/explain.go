@@ -0,0 +1,132 @@
+package postgresexplain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Node is a node of a query plan, as reported by EXPLAIN (FORMAT JSON).
+type Node struct {
+	NodeType         string  `json:"Node Type"`
+	RelationName     string  `json:"Relation Name,omitempty"`
+	IndexName        string  `json:"Index Name,omitempty"`
+	ActualRows       float64 `json:"Actual Rows"`
+	ActualLoops      float64 `json:"Actual Loops"`
+	ActualTotalTime  float64 `json:"Actual Total Time"`
+	SharedHitBlocks  int64   `json:"Shared Hit Blocks"`
+	SharedReadBlocks int64   `json:"Shared Read Blocks"`
+	Plans            []Node  `json:"Plans,omitempty"`
+}
+
+// Plan is the plan of a query executed with EXPLAIN (ANALYZE, BUFFERS).
+type Plan struct {
+	// Name is the name the query was explained under, e.g. its sqlc name.
+	Name          string  `json:"Name"`
+	Root          Node    `json:"Plan"`
+	PlanningTime  float64 `json:"Planning Time"`
+	ExecutionTime float64 `json:"Execution Time"`
+}
+
+// Explain executes the query with EXPLAIN (ANALYZE, BUFFERS) and returns its plan. The query runs in a transaction
+// that is rolled back, so the rows it updates and the locks it takes are discarded.
+func Explain(ctx context.Context, conn *pgx.Conn, name string, query string, args ...any) (plan Plan, err error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return Plan{}, fmt.Errorf("error starting transaction to explain %s: %w", name, err)
+	}
+
+	defer func() {
+		if rErr := tx.Rollback(ctx); rErr != nil {
+			err = errors.Join(err, fmt.Errorf("error rolling back transaction of %s: %w", name, rErr))
+		}
+	}()
+
+	var out []byte
+	if err := tx.QueryRow(ctx, "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+query, args...).Scan(&out); err != nil {
+		return Plan{}, fmt.Errorf("error explaining %s: %w", name, err)
+	}
+
+	var plans []Plan
+	if err := json.Unmarshal(out, &plans); err != nil {
+		return Plan{}, fmt.Errorf("error parsing the plan of %s: %w", name, err)
+	}
+
+	if len(plans) != 1 {
+		return Plan{}, fmt.Errorf("error parsing the plan of %s: got %d plans", name, len(plans))
+	}
+
+	plans[0].Name = name
+	return plans[0], nil
+}
+
+// Indexes returns the names of the indexes the plan scans, in alphabetical order.
+func (p Plan) Indexes() []string {
+	seen := make(map[string]struct{})
+	p.Root.walk(func(n Node) {
+		if n.IndexName != "" {
+			seen[n.IndexName] = struct{}{}
+		}
+	})
+
+	indexes := make([]string, 0, len(seen))
+	for index := range seen {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+
+	return indexes
+}
+
+// UsesIndex reports whether the plan scans the index.
+func (p Plan) UsesIndex(index string) bool {
+	for _, i := range p.Indexes() {
+		if i == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Shape renders the plan as an indented tree of its nodes, the relations and the indexes they scan, leaving out the timings
+// and row counts, so plans of different runs only differ if the plan itself changed.
+func (p Plan) Shape() string {
+	var b strings.Builder
+	p.Root.render(&b, 0)
+
+	return b.String()
+}
+
+func (n Node) walk(fn func(Node)) {
+	fn(n)
+	for _, child := range n.Plans {
+		child.walk(fn)
+	}
+}
+
+func (n Node) render(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	if depth > 0 {
+		b.WriteString("-> ")
+	}
+
+	b.WriteString(n.NodeType)
+	if n.IndexName != "" {
+		b.WriteString(" using " + n.IndexName)
+	}
+
+	if n.RelationName != "" {
+		b.WriteString(" on " + n.RelationName)
+	}
+	b.WriteString("\n")
+
+	for _, child := range n.Plans {
+		child.render(b, depth+1)
+	}
+}
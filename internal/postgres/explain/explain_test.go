@@ -0,0 +1,34 @@
+package postgresexplain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// lookupPlan is the plan of GetSeatWithExclusiveLock on a trip with free seats.
+const lookupPlan = `[{"Plan": {"Node Type": "Limit", "Actual Rows": 1, "Actual Loops": 1, "Plans": [
+  {"Node Type": "LockRows", "Actual Rows": 1, "Actual Loops": 1, "Plans": [
+    {"Node Type": "Index Scan", "Relation Name": "reservation", "Index Name": "idx_reservation_trip_id_passenger_id_seat_id",
+     "Actual Rows": 1, "Actual Loops": 1, "Shared Hit Blocks": 4}]}]},
+  "Planning Time": 0.21, "Execution Time": 0.05}]`
+
+func TestPlan(t *testing.T) {
+	var plans []Plan
+	if err := json.Unmarshal([]byte(lookupPlan), &plans); err != nil {
+		t.Fatalf("error parsing plan: %v", err)
+	}
+	plan := plans[0]
+
+	if !plan.UsesIndex("idx_reservation_trip_id_passenger_id_seat_id") {
+		t.Errorf("UsesIndex() = false, want true, indexes: %v", plan.Indexes())
+	}
+
+	if plan.UsesIndex("reservation_pkey") {
+		t.Errorf("UsesIndex(reservation_pkey) = true, want false")
+	}
+
+	want := "Limit\n  -> LockRows\n    -> Index Scan using idx_reservation_trip_id_passenger_id_seat_id on reservation\n"
+	if got := plan.Shape(); got != want {
+		t.Errorf("Shape() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package postgreslockgraph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Collector periodically samples pg_locks and pg_blocking_pids() for the backends tagged with a run ID,
+// on a connection of its own. Samples without any lock waits are not kept.
+type Collector struct {
+	q        *store.Queries
+	runID    string
+	interval time.Duration
+
+	mu        sync.Mutex
+	snapshots []Snapshot
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func NewCollector(conn *pgx.Conn, runID string, interval time.Duration) *Collector {
+	return &Collector{
+		q:        store.New(conn),
+		runID:    runID,
+		interval: interval,
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start samples the lock waits every interval until Stop is called or ctx is done.
+func (c *Collector) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	go func() {
+		defer close(c.stopped)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.sample(ctx); err != nil && ctx.Err() == nil {
+					logrus.WithError(err).Warn("error sampling lock waits")
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops sampling and returns the snapshots taken.
+func (c *Collector) Stop() []Snapshot {
+	c.cancel()
+	<-c.stopped
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.snapshots
+}
+
+func (c *Collector) sample(ctx context.Context) error {
+	rows, err := c.q.GetLockWaits(ctx, pgactivity.RunPattern(c.runID))
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	nodes := make(map[int32]Node)
+	snapshot := Snapshot{At: time.Now(), Edges: make([]Edge, 0, len(rows))}
+	for _, row := range rows {
+		nodes[row.WaiterPid] = newNode(row.WaiterPid, row.WaiterApplicationName)
+		nodes[row.HolderPid] = newNode(row.HolderPid, row.HolderApplicationName)
+
+		snapshot.Edges = append(snapshot.Edges, Edge{
+			Waiter:   row.WaiterPid,
+			Holder:   row.HolderPid,
+			LockType: row.LockType,
+			LockMode: row.LockMode,
+			Relation: row.Relation,
+		})
+	}
+
+	snapshot.Nodes = make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool { return snapshot.Nodes[i].PID < snapshot.Nodes[j].PID })
+
+	c.mu.Lock()
+	c.snapshots = append(c.snapshots, snapshot)
+	c.mu.Unlock()
+
+	return nil
+}
+
+func newNode(pid int32, applicationName string) Node {
+	tag, tagged := pgactivity.ParseTag(applicationName)
+	return Node{PID: pid, Tag: tag, Tagged: tagged}
+}
@@ -0,0 +1,50 @@
+package postgreslockgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the graph in the Graphviz DOT language, edges point from the waiting backend to the backend it waits for.
+func WriteDOT(w io.Writer, g Graph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph lock_waits {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %d [label=%s];\n", node.PID, strconv.Quote(node.Label()))
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %d -> %d [label=%s, penwidth=%d];\n",
+			edge.Waiter,
+			edge.Holder,
+			strconv.Quote(fmt.Sprintf("%s x%d", edge.lock(), edge.Snapshots)),
+			min(1+edge.Snapshots/10, 5),
+		)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the snapshots and the graph aggregated over them as JSON.
+func WriteJSON(w io.Writer, snapshots []Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Snapshots []Snapshot `json:"snapshots"`
+		Graph     Graph      `json:"graph"`
+	}{
+		Snapshots: snapshots,
+		Graph:     Aggregate(snapshots),
+	})
+}
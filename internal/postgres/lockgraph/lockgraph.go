@@ -0,0 +1,131 @@
+package postgreslockgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+)
+
+// Node is a backend in the wait-for graph, annotated with the booking it worked on if its application_name is tagged.
+type Node struct {
+	PID    int32          `json:"pid"`
+	Tag    pgactivity.Tag `json:"tag"`
+	Tagged bool           `json:"tagged"`
+	// Seat is the seat claimed by the booking attempt of the backend, if known.
+	Seat string `json:"seat,omitempty"`
+}
+
+// Label describes the backend and its booking, one item per line.
+func (n Node) Label() string {
+	lines := []string{fmt.Sprintf("pid %d", n.PID)}
+	if n.Tagged && n.Tag.PassengerID > 0 {
+		lines = append(lines, fmt.Sprintf("passenger %d attempt %d", n.Tag.PassengerID, n.Tag.Attempt))
+	}
+
+	if n.Seat != "" {
+		lines = append(lines, "seat "+n.Seat)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Edge means the waiter backend is waiting for a lock the holder backend holds, or is queued ahead of it for.
+type Edge struct {
+	Waiter   int32  `json:"waiter"`
+	Holder   int32  `json:"holder"`
+	LockType string `json:"lock_type"`
+	LockMode string `json:"lock_mode"`
+	Relation string `json:"relation,omitempty"`
+}
+
+func (e Edge) lock() string {
+	lock := e.LockType + " " + e.LockMode
+	if e.Relation != "" {
+		lock += " on " + e.Relation
+	}
+
+	return lock
+}
+
+// Snapshot is the wait-for graph of the backends of a run at a point in time.
+type Snapshot struct {
+	At    time.Time `json:"at"`
+	Nodes []Node    `json:"nodes"`
+	Edges []Edge    `json:"edges"`
+}
+
+// WaitEdge is an edge of the wait-for graph aggregated over the snapshots it was seen in.
+type WaitEdge struct {
+	Edge
+	Snapshots int       `json:"snapshots"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Graph is the wait-for graph aggregated over all the snapshots of a run.
+type Graph struct {
+	Nodes []Node     `json:"nodes"`
+	Edges []WaitEdge `json:"edges"`
+}
+
+// Aggregate merges the snapshots into a single graph, every edge records how many snapshots it was seen in.
+func Aggregate(snapshots []Snapshot) Graph {
+	nodes := make(map[int32]Node)
+	edges := make(map[Edge]*WaitEdge)
+
+	for _, snapshot := range snapshots {
+		for _, node := range snapshot.Nodes {
+			// Keep the latest booking of the backend, a pooled connection works on many bookings during a run
+			nodes[node.PID] = node
+		}
+
+		for _, edge := range snapshot.Edges {
+			we, ok := edges[edge]
+			if !ok {
+				we = &WaitEdge{Edge: edge, FirstSeen: snapshot.At}
+				edges[edge] = we
+			}
+
+			we.Snapshots++
+			we.LastSeen = snapshot.At
+		}
+	}
+
+	g := Graph{Nodes: make([]Node, 0, len(nodes)), Edges: make([]WaitEdge, 0, len(edges))}
+	for _, node := range nodes {
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	for _, edge := range edges {
+		g.Edges = append(g.Edges, *edge)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].PID < g.Nodes[j].PID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Waiter != g.Edges[j].Waiter {
+			return g.Edges[i].Waiter < g.Edges[j].Waiter
+		}
+
+		return g.Edges[i].Holder < g.Edges[j].Holder
+	})
+
+	return g
+}
+
+// Annotate sets the seat of every tagged node to the seat claimed by its booking attempt, as returned by seat.
+func Annotate(snapshots []Snapshot, seat func(passengerID int32, attempt int) (string, bool)) {
+	for _, snapshot := range snapshots {
+		for i, node := range snapshot.Nodes {
+			if !node.Tagged || node.Tag.PassengerID == 0 {
+				continue
+			}
+
+			if seatID, ok := seat(node.Tag.PassengerID, node.Tag.Attempt); ok {
+				snapshot.Nodes[i].Seat = seatID
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package postgreslockgraph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+)
+
+func TestAggregate(t *testing.T) {
+	start := time.Now()
+	waiter := Node{PID: 11, Tag: pgactivity.Tag{RunID: "run", Slot: 1, PassengerID: 7, Attempt: 2}, Tagged: true}
+	holder := Node{PID: 12, Tag: pgactivity.Tag{RunID: "run", Slot: 2, PassengerID: 8, Attempt: 1}, Tagged: true}
+	rowLock := Edge{Waiter: 11, Holder: 12, LockType: "transactionid", LockMode: "ShareLock"}
+	tupleLock := Edge{Waiter: 13, Holder: 11, LockType: "tuple", LockMode: "ExclusiveLock", Relation: "reservation"}
+
+	snapshots := []Snapshot{
+		{At: start, Nodes: []Node{waiter, holder}, Edges: []Edge{rowLock}},
+		{At: start.Add(time.Second), Nodes: []Node{waiter, holder, {PID: 13}}, Edges: []Edge{rowLock, tupleLock}},
+	}
+
+	Annotate(snapshots, func(passengerID int32, attempt int) (string, bool) {
+		if passengerID == 7 && attempt == 2 {
+			return "12C", true
+		}
+
+		return "", false
+	})
+
+	g := Aggregate(snapshots)
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("Aggregate() = %d nodes, %d edges, want 3 nodes, 2 edges", len(g.Nodes), len(g.Edges))
+	}
+
+	if g.Nodes[0].Seat != "12C" {
+		t.Errorf("seat of pid 11 = %q, want 12C", g.Nodes[0].Seat)
+	}
+
+	if e := g.Edges[0]; e.Edge != rowLock || e.Snapshots != 2 || !e.FirstSeen.Equal(start) || !e.LastSeen.Equal(start.Add(time.Second)) {
+		t.Errorf("first edge = %+v, want %+v seen in 2 snapshots", e, rowLock)
+	}
+
+	var dot strings.Builder
+	if err := WriteDOT(&dot, g); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	for _, want := range []string{`11 [label="pid 11\npassenger 7 attempt 2\nseat 12C"]`, `11 -> 12 [label="transactionid ShareLock x2"`} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("WriteDOT() = %s, want it to contain %s", dot.String(), want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package postgresserverstats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// Counters are the cumulative statistics of the database and of one of its tables.
+// The difference of two snapshots, see Delta, is the work the server did in between.
+type Counters struct {
+	Deadlocks int64 `json:"deadlocks"`
+	// Conflicts are the queries canceled due to conflicts with recovery, only counted on standby servers.
+	Conflicts    int64 `json:"conflicts"`
+	XactCommit   int64 `json:"xact_commit"`
+	XactRollback int64 `json:"xact_rollback"`
+	TupUpdated   int64 `json:"tup_updated"`
+	// WALBytes is the WAL position of the server, its delta is the amount of WAL generated.
+	WALBytes int64         `json:"wal_bytes"`
+	Table    TableCounters `json:"table"`
+}
+
+// TableCounters are the statistics of a table from pg_stat_user_tables.
+type TableCounters struct {
+	Name       string `json:"name"`
+	Updates    int64  `json:"n_tup_upd"`
+	HOTUpdates int64  `json:"n_tup_hot_upd"`
+	// DeadTuples is an estimate of the number of dead rows, it isn't cumulative and drops when the table is vacuumed.
+	DeadTuples int64 `json:"n_dead_tup"`
+}
+
+// Snapshot reads the current statistics of the database and of the given table.
+// The statistics of a backend are only visible to the others once it flushes them, at the latest when it exits.
+func Snapshot(ctx context.Context, q *store.Queries, table string) (Counters, error) {
+	db, err := q.GetDatabaseStats(ctx)
+	if err != nil {
+		return Counters{}, fmt.Errorf("error reading pg_stat_database: %w", err)
+	}
+
+	t, err := q.GetTableStats(ctx, table)
+	if err != nil {
+		return Counters{}, fmt.Errorf("error reading pg_stat_user_tables for %s: %w", table, err)
+	}
+
+	return Counters{
+		Deadlocks:    db.Deadlocks,
+		Conflicts:    db.Conflicts,
+		XactCommit:   db.XactCommit,
+		XactRollback: db.XactRollback,
+		TupUpdated:   db.TupUpdated,
+		WALBytes:     db.WalLsn,
+		Table: TableCounters{
+			Name:       table,
+			Updates:    t.NTupUpd,
+			HOTUpdates: t.NTupHotUpd,
+			DeadTuples: t.NDeadTup,
+		},
+	}, nil
+}
+
+// Delta returns the change of the counters from before to after.
+func Delta(before, after Counters) Counters {
+	return Counters{
+		Deadlocks:    after.Deadlocks - before.Deadlocks,
+		Conflicts:    after.Conflicts - before.Conflicts,
+		XactCommit:   after.XactCommit - before.XactCommit,
+		XactRollback: after.XactRollback - before.XactRollback,
+		TupUpdated:   after.TupUpdated - before.TupUpdated,
+		WALBytes:     after.WALBytes - before.WALBytes,
+		Table: TableCounters{
+			Name:       after.Table.Name,
+			Updates:    after.Table.Updates - before.Table.Updates,
+			HOTUpdates: after.Table.HOTUpdates - before.Table.HOTUpdates,
+			DeadTuples: after.Table.DeadTuples - before.Table.DeadTuples,
+		},
+	}
+}
+
+// HOTRatio returns the share of the updates of the table that were HOT updates, i.e. didn't have to update its indexes.
+func (c Counters) HOTRatio() float64 {
+	if c.Table.Updates == 0 {
+		return 0
+	}
+
+	return float64(c.Table.HOTUpdates) / float64(c.Table.Updates)
+}
@@ -0,0 +1,42 @@
+package postgresserverstats
+
+import (
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	before := Counters{
+		Deadlocks:    2,
+		XactCommit:   1000,
+		XactRollback: 10,
+		TupUpdated:   5000,
+		WALBytes:     1 << 20,
+		Table:        TableCounters{Name: "reservation", Updates: 400, HOTUpdates: 100, DeadTuples: 300},
+	}
+	after := Counters{
+		Deadlocks:    5,
+		XactCommit:   1180,
+		XactRollback: 40,
+		TupUpdated:   5180,
+		WALBytes:     1<<20 + 65536,
+		Table:        TableCounters{Name: "reservation", Updates: 580, HOTUpdates: 145, DeadTuples: 120},
+	}
+
+	want := Counters{
+		Deadlocks:    3,
+		XactCommit:   180,
+		XactRollback: 30,
+		TupUpdated:   180,
+		WALBytes:     65536,
+		Table:        TableCounters{Name: "reservation", Updates: 180, HOTUpdates: 45, DeadTuples: -180},
+	}
+
+	got := Delta(before, after)
+	if got != want {
+		t.Fatalf("Delta() = %+v, want %+v", got, want)
+	}
+
+	if ratio := got.HOTRatio(); ratio != 0.25 {
+		t.Errorf("HOTRatio() = %v, want 0.25", ratio)
+	}
+}
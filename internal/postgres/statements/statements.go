@@ -0,0 +1,104 @@
+package postgresstatements
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+// maxUnnamedQueryLen is the length the text of queries without a sqlc name is shortened to.
+const maxUnnamedQueryLen = 60
+
+// sqlcName matches the "-- name: <name> :<command>" comment sqlc puts at the start of every query it generates.
+var sqlcName = regexp.MustCompile(`^\s*-- name: (\w+) :\w+`)
+
+// Statement is the execution statistics of a query from pg_stat_statements, summed over all the entries with the same name.
+type Statement struct {
+	// Name is the sqlc name of the query, e.g. GetSeatWithSharedLock, or its normalized text for other queries.
+	Name      string        `json:"name"`
+	Calls     int64         `json:"calls"`
+	TotalTime time.Duration `json:"total_time"`
+	MaxTime   time.Duration `json:"max_time"`
+	Rows      int64         `json:"rows"`
+}
+
+// MeanTime returns the mean execution time of a call of the query.
+func (s Statement) MeanTime() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+
+	return s.TotalTime / time.Duration(s.Calls)
+}
+
+// Reset discards the statistics gathered so far, for all the databases of the server.
+func Reset(ctx context.Context, q *store.Queries) error {
+	if err := q.ResetStatementStats(ctx); err != nil {
+		return fmt.Errorf("error resetting pg_stat_statements: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns the statistics of the queries run in the current database since the last Reset,
+// ordered from the most to the least total execution time.
+func Read(ctx context.Context, q *store.Queries) ([]Statement, error) {
+	rows, err := q.GetStatementStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pg_stat_statements: %w", err)
+	}
+
+	byName := make(map[string]*Statement)
+	for _, row := range rows {
+		name := QueryName(row.Query)
+
+		s, ok := byName[name]
+		if !ok {
+			s = &Statement{Name: name}
+			byName[name] = s
+		}
+
+		s.Calls += row.Calls
+		s.TotalTime += milliseconds(row.TotalExecTime)
+		s.MaxTime = max(s.MaxTime, milliseconds(row.MaxExecTime))
+		s.Rows += row.Rows
+	}
+
+	statements := make([]Statement, 0, len(byName))
+	for _, s := range byName {
+		statements = append(statements, *s)
+	}
+
+	sort.Slice(statements, func(i, j int) bool {
+		if statements[i].TotalTime != statements[j].TotalTime {
+			return statements[i].TotalTime > statements[j].TotalTime
+		}
+
+		return statements[i].Name < statements[j].Name
+	})
+
+	return statements, nil
+}
+
+// QueryName returns the sqlc name of the query, or its text with the whitespace collapsed if it wasn't generated by sqlc.
+func QueryName(query string) string {
+	if m := sqlcName.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+
+	name := strings.Join(strings.Fields(query), " ")
+	if len(name) > maxUnnamedQueryLen {
+		name = name[:maxUnnamedQueryLen-3] + "..."
+	}
+
+	return name
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,32 @@
+package postgresstatements
+
+import (
+	"testing"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: store.GetSeatWithSharedLock, want: "GetSeatWithSharedLock"},
+		{query: store.BookSeat, want: "BookSeat"},
+		{query: store.ClaimSeatWithExclusiveLock, want: "ClaimSeatWithExclusiveLock"},
+		{query: "begin isolation level read committed", want: "begin isolation level read committed"},
+		{query: "SELECT set_config($1, $2, false)", want: "SELECT set_config($1, $2, false)"},
+		{
+			query: "SELECT id, seat_id\n  FROM reservation\n WHERE trip_id = p_trip_id AND passenger_id IS NULL ORDER BY id LIMIT $1",
+			want:  "SELECT id, seat_id FROM reservation WHERE trip_id = p_tri...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := QueryName(tt.query); got != tt.want {
+				t.Errorf("QueryName(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
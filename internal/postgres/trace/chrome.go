@@ -0,0 +1,240 @@
+package postgrestrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The trace-event processes: one track per pool connection, and one per passenger for the time spent waiting on the client.
+const (
+	connectionsPID = 1
+	bookingsPID    = 2
+)
+
+const (
+	deadlockDetected     = "40P01"
+	serializationFailure = "40001"
+)
+
+// chromeEvent is an event of the Trace Event Format, as read by chrome://tracing and Perfetto.
+type chromeEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Ph    string         `json:"ph"`
+	Ts    float64        `json:"ts"`
+	Dur   float64        `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the events in the Trace Event Format, which opens in chrome://tracing and Perfetto.
+// Every pool connection gets a track with a span per transaction, nested spans for its statements, e.g. BEGIN, seat lock,
+// update and COMMIT, and instant events for deadlocks and serialization failures. Every passenger gets a track with
+// the time spent waiting for a connection and backing off.
+func WriteChromeTrace(w io.Writer, events []Event) error {
+	if len(events) == 0 {
+		return json.NewEncoder(w).Encode(map[string]any{"traceEvents": []chromeEvent{}})
+	}
+
+	origin := events[0].Start
+	for _, e := range events {
+		if e.Start.Before(origin) {
+			origin = e.Start
+		}
+	}
+	ts := func(t time.Time) float64 { return float64(t.Sub(origin).Nanoseconds()) / 1e3 }
+	dur := func(d time.Duration) float64 { return float64(d.Nanoseconds()) / 1e3 }
+
+	traceEvents := []chromeEvent{
+		{Name: "process_name", Ph: "M", PID: connectionsPID, Args: map[string]any{"name": "pool connections"}},
+		{Name: "process_name", Ph: "M", PID: bookingsPID, Args: map[string]any{"name": "bookings"}},
+	}
+
+	slots := make(map[int]struct{})
+	passengers := make(map[int32]struct{})
+	for _, e := range events {
+		args := map[string]any{"passenger": e.PassengerID, "attempt": e.Attempt}
+		if e.Err != "" {
+			args["error"] = e.Err
+		}
+
+		if e.Kind != Statement {
+			passengers[e.PassengerID] = struct{}{}
+			traceEvents = append(traceEvents, chromeEvent{
+				Name: phase(e),
+				Cat:  string(e.Kind),
+				Ph:   "X",
+				Ts:   ts(e.Start),
+				Dur:  dur(e.Duration),
+				PID:  bookingsPID,
+				TID:  int(e.PassengerID),
+				Args: args,
+			})
+
+			continue
+		}
+
+		slots[e.Slot] = struct{}{}
+		args["statement"] = e.Name
+		args["pid"] = e.PID
+		traceEvents = append(traceEvents, chromeEvent{
+			Name: phase(e),
+			Cat:  string(e.Kind),
+			Ph:   "X",
+			Ts:   ts(e.Start),
+			Dur:  dur(e.Duration),
+			PID:  connectionsPID,
+			TID:  e.Slot,
+			Args: args,
+		})
+
+		if name, ok := failureName(e.SQLState); ok {
+			traceEvents = append(traceEvents, chromeEvent{
+				Name:  name,
+				Cat:   "error",
+				Ph:    "i",
+				Ts:    ts(e.Start.Add(e.Duration)),
+				PID:   connectionsPID,
+				TID:   e.Slot,
+				Scope: "t",
+				Args:  args,
+			})
+		}
+	}
+
+	for _, tx := range transactions(events) {
+		traceEvents = append(traceEvents, chromeEvent{
+			Name: fmt.Sprintf("transaction p%d a%d", tx.passengerID, tx.attempt),
+			Cat:  "transaction",
+			Ph:   "X",
+			Ts:   ts(tx.start),
+			Dur:  dur(tx.end.Sub(tx.start)),
+			PID:  connectionsPID,
+			TID:  tx.slot,
+			Args: map[string]any{"passenger": tx.passengerID, "attempt": tx.attempt},
+		})
+	}
+
+	for slot := range slots {
+		traceEvents = append(traceEvents, chromeEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			PID:  connectionsPID,
+			TID:  slot,
+			Args: map[string]any{"name": fmt.Sprintf("connection %d", slot)},
+		})
+	}
+
+	for passengerID := range passengers {
+		traceEvents = append(traceEvents, chromeEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			PID:  bookingsPID,
+			TID:  int(passengerID),
+			Args: map[string]any{"name": fmt.Sprintf("passenger %d", passengerID)},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(map[string]any{
+		"traceEvents":     traceEvents,
+		"displayTimeUnit": "ms",
+	})
+}
+
+// phase returns the step of the booking the event belongs to.
+func phase(e Event) string {
+	switch e.Kind {
+	case AcquireWait:
+		return "acquire wait"
+	case Backoff:
+		return "backoff"
+	}
+
+	lower := strings.ToLower(e.Name)
+	switch {
+	case strings.HasPrefix(lower, "begin"):
+		return "BEGIN"
+	case strings.HasPrefix(lower, "commit"):
+		return "COMMIT"
+	case strings.HasPrefix(lower, "rollback"):
+		return "ROLLBACK"
+	case strings.HasPrefix(lower, "savepoint"), strings.HasPrefix(lower, "release"):
+		return "savepoint"
+	case strings.HasPrefix(e.Name, "GetSeatWith"):
+		return "seat lock"
+	case strings.HasPrefix(e.Name, "ClaimSeatWith"):
+		return "seat lock and update"
+	case e.Name == "BookSeatWithFunction":
+		return "book_seat"
+	case e.Name == "BookSeat":
+		return "update"
+	}
+
+	return e.Name
+}
+
+func failureName(sqlState string) (string, bool) {
+	switch sqlState {
+	case deadlockDetected:
+		return "deadlock", true
+	case serializationFailure:
+		return "serialization failure", true
+	}
+
+	return "", false
+}
+
+type transaction struct {
+	slot        int
+	passengerID int32
+	attempt     int
+	start, end  time.Time
+}
+
+// transactions returns the span of the statements of every booking attempt on its connection.
+func transactions(events []Event) []transaction {
+	type key struct {
+		slot        int
+		passengerID int32
+		attempt     int
+	}
+
+	byAttempt := make(map[key]*transaction)
+	for _, e := range events {
+		if e.Kind != Statement || e.PassengerID == 0 {
+			continue
+		}
+
+		k := key{slot: e.Slot, passengerID: e.PassengerID, attempt: e.Attempt}
+		end := e.Start.Add(e.Duration)
+
+		tx, ok := byAttempt[k]
+		if !ok {
+			byAttempt[k] = &transaction{slot: e.Slot, passengerID: e.PassengerID, attempt: e.Attempt, start: e.Start, end: end}
+			continue
+		}
+
+		if e.Start.Before(tx.start) {
+			tx.start = e.Start
+		}
+
+		if end.After(tx.end) {
+			tx.end = end
+		}
+	}
+
+	txs := make([]transaction, 0, len(byAttempt))
+	for _, tx := range byAttempt {
+		txs = append(txs, *tx)
+	}
+
+	sort.Slice(txs, func(i, j int) bool { return txs[i].start.Before(txs[j].start) })
+
+	return txs
+}
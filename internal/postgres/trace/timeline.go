@@ -0,0 +1,85 @@
+package postgrestrace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ByPassenger groups the events of the booking attempts by passenger, in the order they started.
+// Events that weren't sent for a booking attempt are left out.
+func ByPassenger(events []Event) map[int32][]Event {
+	byPassenger := make(map[int32][]Event)
+	for _, e := range events {
+		if e.PassengerID > 0 {
+			byPassenger[e.PassengerID] = append(byPassenger[e.PassengerID], e)
+		}
+	}
+
+	for _, passengerEvents := range byPassenger {
+		sort.SliceStable(passengerEvents, func(i, j int) bool { return passengerEvents[i].Start.Before(passengerEvents[j].Start) })
+	}
+
+	return byPassenger
+}
+
+// WriteTimeline writes the timeline of the events of every passenger, with their start relative to the first event.
+func WriteTimeline(w io.Writer, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	origin := events[0].Start
+	for _, e := range events {
+		if e.Start.Before(origin) {
+			origin = e.Start
+		}
+	}
+
+	byPassenger := ByPassenger(events)
+	passengerIDs := make([]int32, 0, len(byPassenger))
+	for passengerID := range byPassenger {
+		passengerIDs = append(passengerIDs, passengerID)
+	}
+	sort.Slice(passengerIDs, func(i, j int) bool { return passengerIDs[i] < passengerIDs[j] })
+
+	var b strings.Builder
+	for _, passengerID := range passengerIDs {
+		fmt.Fprintf(&b, "passenger %d\n", passengerID)
+		for _, e := range byPassenger[passengerID] {
+			fmt.Fprintf(&b, "  %12v  attempt %d  slot %3d  pid %6d  %10v  %s",
+				e.Start.Sub(origin).Round(time.Microsecond),
+				e.Attempt,
+				e.Slot,
+				e.PID,
+				e.Duration.Round(time.Microsecond),
+				eventName(e),
+			)
+			if e.Batch {
+				b.WriteString(" (batch)")
+			}
+
+			if e.Err != "" {
+				b.WriteString("  failed: " + e.Err)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func eventName(e Event) string {
+	switch e.Kind {
+	case AcquireWait:
+		return "(waiting for a connection)"
+	case Backoff:
+		return "(backing off)"
+	default:
+		return e.Name
+	}
+}
@@ -0,0 +1,184 @@
+package postgrestrace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgactivity "github.com/soumya-codes/airline-reservation-poc/internal/postgres/activity"
+	pgstatements "github.com/soumya-codes/airline-reservation-poc/internal/postgres/statements"
+)
+
+// Kind is the kind of a trace event.
+type Kind string
+
+const (
+	// Statement is a statement sent to Postgres, as recorded by the Tracer.
+	Statement Kind = "statement"
+	// AcquireWait is the time a booking attempt waited for a pool connection.
+	AcquireWait Kind = "acquire"
+	// Backoff is the time a booking slept before retrying a failed attempt.
+	Backoff Kind = "backoff"
+)
+
+// Event is a statement sent to Postgres, or the time a booking spent waiting on the client.
+type Event struct {
+	Kind Kind `json:"kind"`
+	// Name is the sqlc name of the statement, or its text if it wasn't generated by sqlc.
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// PID is the process ID of the backend of the connection.
+	PID uint32 `json:"pid"`
+	// Slot is the pool slot of the connection, -1 if the connection doesn't belong to a pool.
+	Slot        int    `json:"slot"`
+	PassengerID int32  `json:"passenger_id,omitempty"`
+	Attempt     int    `json:"attempt,omitempty"`
+	Batch       bool   `json:"batch,omitempty"`
+	Err         string `json:"error,omitempty"`
+	// SQLState is the SQLSTATE of the error, if it was reported by Postgres.
+	SQLState string `json:"sqlstate,omitempty"`
+}
+
+// Buffer is an in-memory buffer of trace events, it keeps the first capacity events and counts the ones it drops.
+// It is safe for concurrent use.
+type Buffer struct {
+	mu       sync.Mutex
+	events   []Event
+	capacity int
+	dropped  int
+}
+
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{events: make([]Event, 0, min(capacity, 1024)), capacity: capacity}
+}
+
+// Add records the event, or drops it if the buffer is full. Adding to a nil buffer is a no-op.
+func (b *Buffer) Add(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.events) >= b.capacity {
+		b.dropped++
+		return
+	}
+
+	b.events = append(b.events, e)
+}
+
+// Events returns a copy of the events recorded so far, in the order they ended.
+func (b *Buffer) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Event(nil), b.events...)
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (b *Buffer) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dropped
+}
+
+type attemptKey struct{}
+
+type attempt struct {
+	passengerID int32
+	attempt     int
+}
+
+// WithAttempt returns a context that attributes the statements sent with it to the booking attempt of the passenger.
+func WithAttempt(ctx context.Context, passengerID int32, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt{passengerID: passengerID, attempt: n})
+}
+
+// Attempt returns the passenger and attempt the context was tagged with by WithAttempt.
+func Attempt(ctx context.Context) (passengerID int32, n int, ok bool) {
+	a, ok := ctx.Value(attemptKey{}).(attempt)
+	return a.passengerID, a.attempt, ok
+}
+
+// Tracer is a pgx QueryTracer and BatchTracer that records every statement sent on a connection into a Buffer.
+type Tracer struct {
+	buffer *Buffer
+}
+
+func NewTracer(buffer *Buffer) *Tracer {
+	return &Tracer{buffer: buffer}
+}
+
+type startKey struct{}
+
+type start struct {
+	at  time.Time
+	sql string
+}
+
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, startKey{}, &start{at: time.Now(), sql: data.SQL})
+}
+
+func (t *Tracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	s, ok := ctx.Value(startKey{}).(*start)
+	if !ok {
+		return
+	}
+
+	t.record(ctx, conn, s.sql, s.at, time.Now(), false, data.Err)
+}
+
+// TraceBatchStart records the start of the batch, the queries of a batch are sent together, so the duration of each of them
+// is the time from the end of the previous one.
+func (t *Tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return context.WithValue(ctx, startKey{}, &start{at: time.Now()})
+}
+
+func (t *Tracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	s, ok := ctx.Value(startKey{}).(*start)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	t.record(ctx, conn, data.SQL, s.at, end, true, data.Err)
+	s.at = end
+}
+
+func (t *Tracer) TraceBatchEnd(context.Context, *pgx.Conn, pgx.TraceBatchEndData) {}
+
+func (t *Tracer) record(ctx context.Context, conn *pgx.Conn, sql string, start time.Time, end time.Time, batch bool, err error) {
+	e := Event{
+		Kind:     Statement,
+		Name:     pgstatements.QueryName(sql),
+		Start:    start,
+		Duration: end.Sub(start),
+		PID:      conn.PgConn().PID(),
+		Slot:     -1,
+		Batch:    batch,
+	}
+
+	if tag, ok := pgactivity.ParseTag(conn.Config().RuntimeParams["application_name"]); ok {
+		e.Slot = tag.Slot
+	}
+
+	e.PassengerID, e.Attempt, _ = Attempt(ctx)
+	if err != nil {
+		e.Err = err.Error()
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			e.SQLState = pgErr.Code
+		}
+	}
+
+	t.buffer.Add(e)
+}
@@ -0,0 +1,116 @@
+package postgrestrace
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuffer(t *testing.T) {
+	buffer := NewBuffer(2)
+	for i := 0; i < 3; i++ {
+		buffer.Add(Event{Name: "BookSeat"})
+	}
+
+	if got := len(buffer.Events()); got != 2 {
+		t.Errorf("len(Events()) = %d, want 2", got)
+	}
+
+	if got := buffer.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+}
+
+func TestWithAttempt(t *testing.T) {
+	if _, _, ok := Attempt(context.Background()); ok {
+		t.Errorf("Attempt() of a context without attempt reported ok")
+	}
+
+	passengerID, attempt, ok := Attempt(WithAttempt(context.Background(), 42, 3))
+	if !ok || passengerID != 42 || attempt != 3 {
+		t.Errorf("Attempt() = %d, %d, %v, want 42, 3, true", passengerID, attempt, ok)
+	}
+}
+
+func TestWriteTimeline(t *testing.T) {
+	start := time.Now()
+	events := []Event{
+		{Name: "commit", Start: start.Add(3 * time.Millisecond), Duration: time.Millisecond, PID: 11, Slot: 1, PassengerID: 7, Attempt: 1},
+		{Name: "begin", Start: start, Duration: time.Millisecond, PID: 11, Slot: 1, PassengerID: 7, Attempt: 1},
+		{Name: "GetPassengers", Start: start, Duration: time.Millisecond, PID: 10, Slot: -1},
+		{Name: "GetSeatWithExclusiveLock", Start: start, Duration: 2 * time.Millisecond, PID: 12, Slot: 2, PassengerID: 8, Attempt: 2, Err: "deadlock detected"},
+	}
+
+	byPassenger := ByPassenger(events)
+	if len(byPassenger) != 2 || byPassenger[7][0].Name != "begin" {
+		t.Fatalf("ByPassenger() = %+v, want 2 passengers with the events of passenger 7 in start order", byPassenger)
+	}
+
+	var timeline strings.Builder
+	if err := WriteTimeline(&timeline, events); err != nil {
+		t.Fatalf("WriteTimeline() error = %v", err)
+	}
+
+	got := timeline.String()
+	if strings.Contains(got, "GetPassengers") {
+		t.Errorf("WriteTimeline() = %s, want it to leave out the events of no passenger", got)
+	}
+
+	if strings.Index(got, "passenger 7") > strings.Index(got, "passenger 8") || !strings.Contains(got, "failed: deadlock detected") {
+		t.Errorf("WriteTimeline() = %s, want passenger 7 before passenger 8 and the deadlock of passenger 8", got)
+	}
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	start := time.Now()
+	events := []Event{
+		{Kind: AcquireWait, Name: "acquire", Start: start, Duration: time.Millisecond, Slot: 1, PassengerID: 7, Attempt: 1},
+		{Kind: Statement, Name: "begin", Start: start.Add(time.Millisecond), Duration: time.Millisecond, PID: 11, Slot: 1, PassengerID: 7, Attempt: 1},
+		{Kind: Statement, Name: "GetSeatWithExclusiveLock", Start: start.Add(2 * time.Millisecond), Duration: 5 * time.Millisecond, PID: 11, Slot: 1, PassengerID: 7, Attempt: 1, Err: "deadlock detected", SQLState: "40P01"},
+		{Kind: Statement, Name: "rollback", Start: start.Add(7 * time.Millisecond), Duration: time.Millisecond, PID: 11, Slot: 1, PassengerID: 7, Attempt: 1},
+		{Kind: Backoff, Name: "backoff", Start: start.Add(8 * time.Millisecond), Duration: 30 * time.Millisecond, PassengerID: 7, Attempt: 1},
+	}
+
+	var out strings.Builder
+	if err := WriteChromeTrace(&out, events); err != nil {
+		t.Fatalf("WriteChromeTrace() error = %v", err)
+	}
+
+	var trace struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &trace); err != nil {
+		t.Fatalf("error parsing trace: %v", err)
+	}
+
+	got := make(map[string]chromeEvent)
+	for _, e := range trace.TraceEvents {
+		got[e.Name] = e
+	}
+
+	for _, want := range []struct {
+		name string
+		ph   string
+		pid  int
+		tid  int
+	}{
+		{name: "acquire wait", ph: "X", pid: bookingsPID, tid: 7},
+		{name: "BEGIN", ph: "X", pid: connectionsPID, tid: 1},
+		{name: "seat lock", ph: "X", pid: connectionsPID, tid: 1},
+		{name: "deadlock", ph: "i", pid: connectionsPID, tid: 1},
+		{name: "ROLLBACK", ph: "X", pid: connectionsPID, tid: 1},
+		{name: "backoff", ph: "X", pid: bookingsPID, tid: 7},
+		{name: "transaction p7 a1", ph: "X", pid: connectionsPID, tid: 1},
+	} {
+		e, ok := got[want.name]
+		if !ok || e.Ph != want.ph || e.PID != want.pid || e.TID != want.tid {
+			t.Errorf("event %s = %+v, want ph %s on pid %d tid %d", want.name, e, want.ph, want.pid, want.tid)
+		}
+	}
+
+	if tx := got["transaction p7 a1"]; tx.Ts != 1000 || tx.Dur != 7000 {
+		t.Errorf("transaction span = %v+%vµs, want 1000+7000µs", tx.Ts, tx.Dur)
+	}
+}
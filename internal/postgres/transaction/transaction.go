@@ -2,30 +2,205 @@ package postgrestransaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type IsolationLevel string
 
 const (
-	//ReadUncommitted = "READ UNCOMMITTED"
-	ReadCommitted  = "READ COMMITTED"
-	RepeatableRead = "REPEATABLE READ"
-	Serializable   = "SERIALIZABLE"
+	// ReadUncommitted behaves like ReadCommitted in Postgres, it is supported for completeness of the experiments.
+	ReadUncommitted IsolationLevel = "READ UNCOMMITTED"
+	ReadCommitted   IsolationLevel = "READ COMMITTED"
+	RepeatableRead  IsolationLevel = "REPEATABLE READ"
+	Serializable    IsolationLevel = "SERIALIZABLE"
 )
 
-func BeginTxWithIsolationLevel(ctx context.Context, conn *pgx.Conn, isolationLevel IsolationLevel) (pgx.Tx, error) {
-	tx, err := conn.Begin(ctx)
+type AccessMode string
+
+const (
+	ReadWrite AccessMode = "READ WRITE"
+	ReadOnly  AccessMode = "READ ONLY"
+)
+
+const (
+	serializationFailure = "40001"
+)
+
+// Options describes how a transaction is started and how WithTx retries it on serialization failures.
+// The zero value starts a transaction with the server defaults and does not retry.
+type Options struct {
+	IsolationLevel IsolationLevel
+	AccessMode     AccessMode
+	// Deferrable only has an effect on SERIALIZABLE READ ONLY transactions.
+	Deferrable bool
+	// MaxRetries is the number of times WithTx retries the transaction after a serialization failure.
+	MaxRetries int
+	// RetryInterval is the time WithTx waits before retrying the transaction.
+	RetryInterval time.Duration
+}
+
+// TxOptions returns the pgx transaction options corresponding to o.
+func (o Options) TxOptions() (pgx.TxOptions, error) {
+	var txOptions pgx.TxOptions
+
+	switch o.IsolationLevel {
+	case "":
+	case ReadUncommitted:
+		txOptions.IsoLevel = pgx.ReadUncommitted
+	case ReadCommitted:
+		txOptions.IsoLevel = pgx.ReadCommitted
+	case RepeatableRead:
+		txOptions.IsoLevel = pgx.RepeatableRead
+	case Serializable:
+		txOptions.IsoLevel = pgx.Serializable
+	default:
+		return pgx.TxOptions{}, fmt.Errorf("unknown isolation level: %s", o.IsolationLevel)
+	}
+
+	switch o.AccessMode {
+	case "":
+	case ReadWrite:
+		txOptions.AccessMode = pgx.ReadWrite
+	case ReadOnly:
+		txOptions.AccessMode = pgx.ReadOnly
+	default:
+		return pgx.TxOptions{}, fmt.Errorf("unknown access mode: %s", o.AccessMode)
+	}
+
+	if o.Deferrable {
+		txOptions.DeferrableMode = pgx.Deferrable
+	}
+
+	return txOptions, nil
+}
+
+// BeginSQL returns the BEGIN statement that starts a transaction with the isolation level, access mode and
+// deferrable mode of opts.
+func (o Options) BeginSQL() (string, error) {
+	if _, err := o.TxOptions(); err != nil {
+		return "", err
+	}
+
+	stmt := "BEGIN"
+	if o.IsolationLevel != "" {
+		stmt += " ISOLATION LEVEL " + string(o.IsolationLevel)
+	}
+
+	if o.AccessMode != "" {
+		stmt += " " + string(o.AccessMode)
+	}
+
+	if o.Deferrable {
+		stmt += " DEFERRABLE"
+	}
+
+	return stmt, nil
+}
+
+// BeginTx starts a transaction on conn with the isolation level, access mode and deferrable mode of opts.
+func BeginTx(ctx context.Context, conn *pgx.Conn, opts Options) (pgx.Tx, error) {
+	txOptions, err := opts.TxOptions()
 	if err != nil {
-		return nil, fmt.Errorf("error starting transaction: %w", err)
+		return nil, err
 	}
 
-	if _, err := tx.Exec(ctx, fmt.Sprintf("SET TRANSACTION ISOLATION LEVEL %s", isolationLevel)); err != nil {
-		rErr := tx.Rollback(ctx)
-		return nil, fmt.Errorf("error setting isolation level: %w", rErr)
+	tx, err := conn.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
 	}
 
 	return tx, nil
 }
+
+func BeginTxWithIsolationLevel(ctx context.Context, conn *pgx.Conn, isolationLevel IsolationLevel) (pgx.Tx, error) {
+	return BeginTx(ctx, conn, Options{IsolationLevel: isolationLevel})
+}
+
+// WithTx runs fn in a transaction started with opts. The transaction is committed if fn succeeds and rolled back otherwise.
+// If fn or the commit fails with a serialization failure, the whole transaction is retried up to opts.MaxRetries times,
+// so fn must not have side effects outside the transaction.
+func WithTx(ctx context.Context, conn *pgx.Conn, opts Options, fn func(tx pgx.Tx) error) error {
+	return retryOnSerializationFailure(ctx, opts, func() error {
+		return runTx(ctx, conn, opts, fn)
+	})
+}
+
+// WithPipelinedTx sends BEGIN, the statements queued by queue and COMMIT to the server as a single batch,
+// so the whole transaction takes one round trip. The results of the queued statements are passed to their callbacks,
+// and the transaction is rolled back if any of them fails. Serialization failures are retried like in WithTx.
+func WithPipelinedTx(ctx context.Context, conn *pgx.Conn, opts Options, queue func(b *pgx.Batch)) error {
+	begin, err := opts.BeginSQL()
+	if err != nil {
+		return err
+	}
+
+	return retryOnSerializationFailure(ctx, opts, func() error {
+		b := &pgx.Batch{}
+		b.Queue(begin)
+		queue(b)
+		b.Queue("COMMIT")
+
+		err := conn.SendBatch(ctx, b).Close()
+		if err == nil {
+			return nil
+		}
+
+		// A failed statement skips the rest of the batch, which leaves the transaction open or aborted.
+		if txStatus := conn.PgConn().TxStatus(); txStatus == 'T' || txStatus == 'E' {
+			if _, rErr := conn.Exec(ctx, "ROLLBACK"); rErr != nil {
+				return fmt.Errorf("%w, error rolling back transaction: %w", err, rErr)
+			}
+		}
+
+		return err
+	})
+}
+
+// retryOnSerializationFailure runs the transaction until it doesn't fail with a serialization failure,
+// or opts.MaxRetries is exhausted.
+func retryOnSerializationFailure(ctx context.Context, opts Options, run func() error) error {
+	for retry := 0; ; retry++ {
+		err := run()
+		if err == nil || !IsSerializationFailure(err) || retry >= opts.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, giving up retrying transaction: %w", err, ctx.Err())
+		case <-time.After(opts.RetryInterval):
+		}
+	}
+}
+
+func runTx(ctx context.Context, conn *pgx.Conn, opts Options, fn func(tx pgx.Tx) error) error {
+	tx, err := BeginTx(ctx, conn, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rErr := tx.Rollback(ctx); rErr != nil {
+			return fmt.Errorf("%w, error rolling back transaction: %w", err, rErr)
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// IsSerializationFailure reports whether err is caused by a serialization failure (SQLSTATE 40001).
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailure
+}
@@ -0,0 +1,79 @@
+package postgrestransaction
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTxOptions(t *testing.T) {
+	tests := []struct {
+		opts    Options
+		want    pgx.TxOptions
+		wantErr bool
+	}{
+		{opts: Options{}, want: pgx.TxOptions{}},
+		{opts: Options{IsolationLevel: ReadUncommitted}, want: pgx.TxOptions{IsoLevel: pgx.ReadUncommitted}},
+		{opts: Options{IsolationLevel: ReadCommitted, AccessMode: ReadWrite}, want: pgx.TxOptions{IsoLevel: pgx.ReadCommitted, AccessMode: pgx.ReadWrite}},
+		{opts: Options{IsolationLevel: RepeatableRead}, want: pgx.TxOptions{IsoLevel: pgx.RepeatableRead}},
+		{
+			opts: Options{IsolationLevel: Serializable, AccessMode: ReadOnly, Deferrable: true},
+			want: pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly, DeferrableMode: pgx.Deferrable},
+		},
+		{opts: Options{IsolationLevel: "SNAPSHOT"}, wantErr: true},
+		{opts: Options{AccessMode: "APPEND ONLY"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt.opts), func(t *testing.T) {
+			got, err := tt.opts.TxOptions()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TxOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("TxOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	serializationErr := fmt.Errorf("error booking seat: %w", &pgconn.PgError{Code: "40001"})
+	if !IsSerializationFailure(serializationErr) {
+		t.Errorf("IsSerializationFailure(%v) = false, want true", serializationErr)
+	}
+
+	deadlockErr := fmt.Errorf("error booking seat: %w", &pgconn.PgError{Code: "40P01"})
+	if IsSerializationFailure(deadlockErr) {
+		t.Errorf("IsSerializationFailure(%v) = true, want false", deadlockErr)
+	}
+}
+
+func TestBeginSQL(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{opts: Options{}, want: "BEGIN"},
+		{opts: Options{IsolationLevel: ReadCommitted}, want: "BEGIN ISOLATION LEVEL READ COMMITTED"},
+		{opts: Options{IsolationLevel: Serializable, AccessMode: ReadOnly, Deferrable: true}, want: "BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY DEFERRABLE"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.opts.BeginSQL()
+		if err != nil {
+			t.Fatalf("BeginSQL() unexpected error: %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("BeginSQL() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if _, err := (Options{IsolationLevel: "READ COMMITTED; DROP TABLE reservation"}).BeginSQL(); err == nil {
+		t.Error("BeginSQL() expected an error for an unknown isolation level")
+	}
+}
@@ -0,0 +1,181 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVHeader is the header of the CSV rows of the runs, durations are in milliseconds.
+var CSVHeader = []string{
+	"run_id", "started_at", "trip_id",
+	"isolation_level", "lock_strategy", "claim_mode", "pool_size", "pool_policy", "max_retries", "savepoint_retries",
+	"query_exec_mode", "cabin_layout", "session_settings",
+	"passengers", "bookings", "attempts", "failed_attempts", "failure_rate",
+	"elapsed_ms", "throughput",
+	"booking_p50_ms", "booking_p90_ms", "booking_p99_ms", "booking_max_ms",
+	"attempt_p99_ms", "acquire_wait_p99_ms", "commit_p99_ms",
+	"kendall_tau", "errors",
+	"deadlocks", "xact_commit", "xact_rollback", "wal_bytes", "hot_ratio",
+}
+
+// CSVRecord returns the CSV row of the run, in the order of CSVHeader.
+// The errors column lists the failures by cause, e.g. "40P01 lock=12; 40001 commit=3".
+func (r *Run) CSVRecord() []string {
+	c := r.Config
+	record := []string{
+		r.RunID, r.StartedAt.Format(time.RFC3339), strconv.Itoa(int(r.TripID)),
+		c.IsolationLevel, c.LockStrategy, c.ClaimMode, strconv.Itoa(c.PoolSize), c.PoolPolicy, strconv.Itoa(c.MaxRetries), strconv.Itoa(c.SavepointRetries),
+		c.QueryExecMode, c.CabinLayout, c.SessionSettings,
+		strconv.Itoa(r.Outcomes.Passengers), strconv.Itoa(r.Outcomes.Bookings), strconv.Itoa(r.Outcomes.Attempts),
+		strconv.Itoa(r.Outcomes.FailedAttempts), formatFloat(r.FailureRate()),
+		milliseconds(r.Elapsed), formatFloat(r.Throughput),
+		milliseconds(r.Latency.Booking.P50), milliseconds(r.Latency.Booking.P90), milliseconds(r.Latency.Booking.P99), milliseconds(r.Latency.Booking.Max),
+		milliseconds(r.Latency.Attempt.P99), milliseconds(r.Latency.AcquireWait.P99), milliseconds(r.Latency.Commit.P99),
+		formatFloat(r.Fairness.KendallTau), errorList(r.Errors),
+	}
+
+	// The server counters are left empty when they couldn't be read
+	if s := r.ServerStats; s != nil {
+		record = append(record, strconv.FormatInt(s.Deadlocks, 10), strconv.FormatInt(s.XactCommit, 10), strconv.FormatInt(s.XactRollback, 10),
+			strconv.FormatInt(s.WALBytes, 10), formatFloat(s.HOTRatio()))
+	} else {
+		record = append(record, "", "", "", "", "")
+	}
+
+	return record
+}
+
+// WriteCSV writes the header and a row per run.
+func WriteCSV(w io.Writer, runs ...*Run) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, r := range runs {
+		if err := cw.Write(r.CSVRecord()); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// AppendCSV appends the row of the run to the CSV file with the name, the header is written if the file is new or empty,
+// so a file collects the rows of every run of an experiment. A file with another header is rotated first, see rotateCSV.
+func AppendCSV(name string, r *Run) error {
+	if err := rotateCSV(name); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", name, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	cw := csv.NewWriter(f)
+	if info.Size() == 0 {
+		_ = cw.Write(CSVHeader)
+	}
+	_ = cw.Write(r.CSVRecord())
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// rotateCSV renames the CSV file with the name to <name>-<time it was last written>.csv if its header isn't CSVHeader,
+// so the rows of the runs are never appended under the header of an older version of the report.
+func rotateCSV(name string) error {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", name, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	header, err := csv.NewReader(f).Read()
+	_ = f.Close()
+	if errors.Is(err, io.EOF) || (err == nil && slices.Equal(header, CSVHeader)) {
+		return nil
+	}
+
+	ext := filepath.Ext(name)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), info.ModTime().UTC().Format("20060102T150405Z"), ext)
+	if err := os.Rename(name, rotated); err != nil {
+		return fmt.Errorf("error rotating %s with an outdated header: %w", name, err)
+	}
+
+	return nil
+}
+
+// WriteSeatsCSV writes the final seat assignment of the run, a row per seat of the trip.
+func WriteSeatsCSV(w io.Writer, r *Run) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"run_id", "trip_id", "seat_id", "row", "letter", "passenger_id", "passenger_name"}); err != nil {
+		return err
+	}
+
+	if r.SeatMap != nil {
+		for _, seat := range r.SeatMap.Seats {
+			passengerID := ""
+			if seat.IsBooked() {
+				passengerID = strconv.Itoa(int(seat.PassengerID))
+			}
+
+			err := cw.Write([]string{r.RunID, strconv.Itoa(int(r.TripID)), seat.ID, strconv.Itoa(seat.Row), seat.Letter, passengerID, seat.PassengerName})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+func errorList(errors []ErrorCount) string {
+	parts := make([]string, 0, len(errors))
+	for _, e := range errors {
+		parts = append(parts, fmt.Sprintf("%s %s=%d", e.SQLState, e.Phase, e.Total))
+	}
+
+	return strings.Join(parts, "; ")
+}
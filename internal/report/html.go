@@ -0,0 +1,330 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chartColours are the fills of the series of the charts, and of the causes of the error breakdown.
+var chartColours = []string{"#4575b4", "#91bfdb", "#fc8d59", "#d73027", "#fee090", "#1a9850", "#762a83", "#999999"}
+
+const (
+	chartWidth = 960
+	// chartLabels is the width of the labels of the bars, the combinations have long names.
+	chartLabels = 400
+	barHeight   = 14
+	barGap      = 3
+	groupGap    = 14
+)
+
+// chartSeries is a series of a bar chart, a bar of every group.
+type chartSeries struct {
+	name   string
+	colour string
+}
+
+// chartBar is the value of a bar, the mean of the runs of its group, and the range of their values.
+type chartBar struct {
+	mean, min, max float64
+}
+
+// chartGroup is the bars of a combination, a bar per series.
+type chartGroup struct {
+	label string
+	bars  []chartBar
+}
+
+// combination is the runs of an isolation level, lock strategy and pool size.
+type combination struct {
+	name string
+	runs []*Run
+}
+
+// combinations groups the runs by combination, in the order the combinations were first run.
+func combinations(runs []*Run) []combination {
+	var groups []combination
+	index := make(map[string]int)
+	for _, run := range runs {
+		name := run.Combination()
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, combination{name: name})
+		}
+		groups[i].runs = append(groups[i].runs, run)
+	}
+
+	return groups
+}
+
+// barOf returns the bar of the value over the runs.
+func barOf(runs []*Run, value func(*Run) float64) chartBar {
+	bar := chartBar{min: value(runs[0]), max: value(runs[0])}
+	for _, run := range runs {
+		v := value(run)
+		bar.mean += v / float64(len(runs))
+		bar.min = min(bar.min, v)
+		bar.max = max(bar.max, v)
+	}
+
+	return bar
+}
+
+func inMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// barChart renders horizontal bars grouped by combination, with a whisker over the range of the runs of a combination.
+func barChart(unit string, series []chartSeries, groups []chartGroup) template.HTML {
+	scale := 0.0
+	for _, group := range groups {
+		for _, bar := range group.bars {
+			scale = max(scale, bar.max)
+		}
+	}
+	if scale == 0 {
+		scale = 1
+	}
+
+	// Leave room for the value after the longest bar
+	x := func(v float64) float64 { return chartLabels + v/scale*(chartWidth-chartLabels-80) }
+	groupHeight := len(series)*(barHeight+barGap) - barGap
+
+	var b strings.Builder
+	y := legend(&b, series)
+	top := y
+	height := y + len(groups)*(groupHeight+groupGap)
+	for _, group := range groups {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartLabels-8, y+groupHeight/2+4, html.EscapeString(group.label))
+
+		for i, bar := range group.bars {
+			by := y + i*(barHeight+barGap)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %.2f %s (%.2f to %.2f)</title></rect>`+"\n",
+				chartLabels, by, x(bar.mean)-chartLabels, barHeight, series[i].colour, html.EscapeString(series[i].name), bar.mean, unit, bar.min, bar.max)
+
+			// The whisker over the range of the runs, if they differ
+			if bar.max > bar.min {
+				mid := by + barHeight/2
+				fmt.Fprintf(&b, `<path d="M%.1f %dH%.1fM%.1f %dV%dM%.1f %dV%d" stroke="#333333" fill="none"/>`+"\n",
+					x(bar.min), mid, x(bar.max), x(bar.min), mid-4, mid+4, x(bar.max), mid-4, mid+4)
+			}
+
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d">%.1f</text>`+"\n", max(x(bar.mean), x(bar.max))+4, by+barHeight-3, bar.mean)
+		}
+
+		y += groupHeight + groupGap
+	}
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333333"/>`+"\n", chartLabels, top-4, chartLabels, y-groupGap+4)
+
+	return svg(height, b.String())
+}
+
+// errorChart renders a stacked bar per combination of the failed attempts per run by cause.
+func errorChart(groups []combination) template.HTML {
+	// The causes, the most frequent over all the runs first
+	totals := make(map[string]float64)
+	perRun := make([]map[string]float64, len(groups))
+	for i, group := range groups {
+		perRun[i] = make(map[string]float64)
+		for _, run := range group.runs {
+			for _, e := range run.Errors {
+				cause := strings.TrimSpace(e.SQLState+" "+e.Name) + " (" + e.Phase + ")"
+				perRun[i][cause] += float64(e.Total) / float64(len(group.runs))
+				totals[cause] += float64(e.Total)
+			}
+		}
+	}
+
+	causes := make([]string, 0, len(totals))
+	for cause := range totals {
+		causes = append(causes, cause)
+	}
+	sort.Slice(causes, func(i, j int) bool {
+		if totals[causes[i]] != totals[causes[j]] {
+			return totals[causes[i]] > totals[causes[j]]
+		}
+		return causes[i] < causes[j]
+	})
+
+	series := make([]chartSeries, len(causes))
+	for i, cause := range causes {
+		series[i] = chartSeries{name: cause, colour: chartColours[i%len(chartColours)]}
+	}
+
+	scale := 0.0
+	for _, failures := range perRun {
+		sum := 0.0
+		for _, n := range failures {
+			sum += n
+		}
+		scale = max(scale, sum)
+	}
+	if scale == 0 {
+		return template.HTML(`<p>No failed attempts.</p>`)
+	}
+
+	var b strings.Builder
+	y := legend(&b, series)
+	height := y + len(groups)*(barHeight+groupGap)
+	for i, group := range groups {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartLabels-8, y+barHeight-3, html.EscapeString(group.name))
+
+		x, sum := float64(chartLabels), 0.0
+		for j, cause := range causes {
+			n := perRun[i][cause]
+			if n == 0 {
+				continue
+			}
+
+			width := n / scale * (chartWidth - chartLabels - 80)
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %.1f per run</title></rect>`+"\n",
+				x, y, width, barHeight, series[j].colour, html.EscapeString(cause), n)
+			x += width
+			sum += n
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%.1f</text>`+"\n", x+4, y+barHeight-3, sum)
+
+		y += barHeight + groupGap
+	}
+
+	return svg(height, b.String())
+}
+
+// legend renders the legend of the series, if there is more than one, and returns the top of the chart below it.
+func legend(b *strings.Builder, series []chartSeries) int {
+	if len(series) < 2 {
+		return 10
+	}
+
+	x, y := chartLabels, 10
+	for _, s := range series {
+		width := 24 + 7*len(s.name)
+		if x+width > chartWidth {
+			x, y = chartLabels, y+18
+		}
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d">%s</text>`+"\n",
+			x, y, s.colour, x+16, y+10, html.EscapeString(s.name))
+		x += width
+	}
+
+	return y + 26
+}
+
+func svg(height int, body string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n%s</svg>",
+		chartWidth, height+10, body))
+}
+
+// htmlRun is a run of the HTML report, with its seat map rendered.
+type htmlRun struct {
+	*Run
+	SeatMapSVG template.HTML
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) string { return fmt.Sprintf("%.1f ms", inMilliseconds(d)) },
+	"percent":  func(f float64) string { return fmt.Sprintf("%.1f%%", 100*f) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #cccccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child, td.text { text-align: left; }
+section.run { border-top: 1px solid #cccccc; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{len .Runs}} runs of {{len .Combinations}} isolation level × lock strategy × pool size combinations, generated at {{.Generated}}.</p>
+
+<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>Started at</th><th>Combination</th><th>Claim mode</th><th>Booked</th><th>Throughput</th><th>p50</th><th>p99</th><th>Failure rate</th><th>Deadlocks</th></tr>
+{{- range .Runs}}
+<tr><td><a href="#run-{{.RunID}}">{{.RunID}}</a></td><td class="text">{{.StartedAt.Format "2006-01-02 15:04:05"}}</td><td class="text">{{.Combination}}</td><td class="text">{{.Config.ClaimMode}}</td>
+<td>{{.Outcomes.Bookings}} / {{.Outcomes.Passengers}}</td><td>{{printf "%.1f" .Throughput}}/s</td><td>{{duration .Latency.Booking.P50}}</td><td>{{duration .Latency.Booking.P99}}</td>
+<td>{{percent .FailureRate}}</td><td>{{with .ServerStats}}{{.Deadlocks}}{{end}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Throughput</h2>
+<p>Bookings per second, the mean of the runs of every combination, the whiskers span the runs.</p>
+{{.Throughput}}
+
+<h2>Booking latency</h2>
+<p>Percentiles of the time from the booking request to the successful booking in milliseconds, the mean of the runs of every combination.</p>
+{{.Latency}}
+
+<h2>Failed attempts</h2>
+<p>Failed attempts per run by SQLSTATE and booking phase.</p>
+{{.Errors}}
+
+<h2>Seat maps</h2>
+{{- range .Runs}}
+<section class="run" id="run-{{.RunID}}">
+<h3>Run {{.RunID}}</h3>
+<p>{{.Combination}}, {{.Config.ClaimMode}}, trip {{.TripID}}: {{.Outcomes.Bookings}} of {{.Outcomes.Passengers}} passengers booked in {{duration .Elapsed}},
+{{.Outcomes.Attempts}} attempts, {{.Outcomes.FailedAttempts}} failed.</p>
+{{if .SeatMap}}<p>{{.SeatMap.Layout}}, {{.SeatMap.Booked}} of {{len .SeatMap.Seats}} seats booked.</p>
+{{.SeatMapSVG}}{{else}}<p>No seat map.</p>{{end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a single self-contained HTML file of the runs, for sharing the results of an experiment offline:
+// the runs, the throughput, latency and error breakdown charts of every isolation level × lock strategy × pool size
+// combination, and the seat map of every run. The charts are inline SVGs, the file doesn't load any external asset.
+func WriteHTML(w io.Writer, title string, runs []*Run) error {
+	groups := combinations(runs)
+
+	throughput := make([]chartGroup, len(groups))
+	latency := make([]chartGroup, len(groups))
+	for i, group := range groups {
+		throughput[i] = chartGroup{label: group.name, bars: []chartBar{
+			barOf(group.runs, func(r *Run) float64 { return r.Throughput }),
+		}}
+		latency[i] = chartGroup{label: group.name, bars: []chartBar{
+			barOf(group.runs, func(r *Run) float64 { return inMilliseconds(r.Latency.Booking.P50) }),
+			barOf(group.runs, func(r *Run) float64 { return inMilliseconds(r.Latency.Booking.P90) }),
+			barOf(group.runs, func(r *Run) float64 { return inMilliseconds(r.Latency.Booking.P99) }),
+		}}
+	}
+
+	htmlRuns := make([]htmlRun, len(runs))
+	for i, run := range runs {
+		htmlRuns[i] = htmlRun{Run: run}
+		if run.SeatMap != nil {
+			var seatMap strings.Builder
+			if err := run.SeatMap.WriteSVG(&seatMap); err != nil {
+				return err
+			}
+			htmlRuns[i].SeatMapSVG = template.HTML(seatMap.String())
+		}
+	}
+
+	return htmlTemplate.Execute(w, map[string]any{
+		"Title":        title,
+		"Generated":    time.Now().Format(time.RFC1123),
+		"Runs":         htmlRuns,
+		"Combinations": groups,
+		"Throughput":   barChart("bookings/s", []chartSeries{{name: "throughput", colour: chartColours[0]}}, throughput),
+		"Latency": barChart("ms", []chartSeries{
+			{name: "p50", colour: chartColours[0]},
+			{name: "p90", colour: chartColours[2]},
+			{name: "p99", colour: chartColours[3]},
+		}, latency),
+		"Errors": errorChart(groups),
+	})
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/histogram"
+)
+
+// WriteMarkdown writes a summary of the run, meant to be pasted in a PR: the configuration, the throughput and latency,
+// the outcomes, the error taxonomy, the server counters and the seat map.
+func WriteMarkdown(w io.Writer, r *Run) error {
+	var b strings.Builder
+	c := r.Config
+
+	fmt.Fprintf(&b, "# Run %s\n\n", r.RunID)
+	fmt.Fprintf(&b, "Trip %d, started at %s, took %v.\n\n", r.TripID, r.StartedAt.Format(time.RFC3339), r.Elapsed.Round(time.Millisecond))
+
+	b.WriteString("## Configuration\n\n")
+	b.WriteString("| Setting | Value |\n|---|---|\n")
+	for _, row := range [][2]string{
+		{"Isolation level", c.IsolationLevel},
+		{"Lock strategy", c.LockStrategy},
+		{"Claim mode", c.ClaimMode},
+		{"Pool size", fmt.Sprint(c.PoolSize)},
+		{"Pool policy", c.PoolPolicy},
+		{"Max retries", fmt.Sprint(c.MaxRetries)},
+		{"Savepoint retries", fmt.Sprint(c.SavepointRetries)},
+		{"Query exec mode", c.QueryExecMode},
+		{"Cabin layout", c.CabinLayout},
+		{"Session settings", c.SessionSettings},
+	} {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], markdownCell(row[1]))
+	}
+
+	b.WriteString("\n## Throughput and latency\n\n")
+	fmt.Fprintf(&b, "%d bookings in %v, %.1f bookings/s.\n\n", r.Outcomes.Bookings, r.Elapsed.Round(time.Millisecond), r.Throughput)
+	b.WriteString("| Latency | count | mean | p50 | p90 | p99 | max |\n|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, row := range []struct {
+		name    string
+		summary histogram.Summary
+	}{
+		{"booking", r.Latency.Booking},
+		{"attempt", r.Latency.Attempt},
+		{"acquire wait", r.Latency.AcquireWait},
+		{"commit", r.Latency.Commit},
+	} {
+		s := row.summary
+		fmt.Fprintf(&b, "| %s | %d | %v | %v | %v | %v | %v |\n",
+			row.name, s.Count, s.Mean.Round(time.Microsecond), s.P50, s.P90, s.P99, s.Max.Round(time.Microsecond))
+	}
+
+	o := r.Outcomes
+	b.WriteString("\n## Outcomes\n\n")
+	fmt.Fprintf(&b, "- %d of %d passengers booked, %d attempts, %d failed (%.1f%%)\n",
+		o.Bookings, o.Passengers, o.Attempts, o.FailedAttempts, 100*r.FailureRate())
+	fmt.Fprintf(&b, "- %d transaction retries, %d savepoint retries, %d round trips\n", o.TransactionRetries, o.SavepointRetries, o.RoundTrips)
+	fmt.Fprintf(&b, "- Seat hold time: mean %v, max %v\n", o.MeanLockHold, o.MaxLockHold)
+	fmt.Fprintf(&b, "- Fairness: Kendall tau distance %.4f, %d inversions, mean displacement %.1f, max displacement %d\n",
+		r.Fairness.KendallTau, r.Fairness.Inversions, r.Fairness.MeanDisplacement, r.Fairness.MaxDisplacement)
+	for _, failures := range sortedKeys(o.BookedAfter) {
+		if failures == 0 {
+			fmt.Fprintf(&b, "- %d booked on the first attempt\n", o.BookedAfter[failures])
+		} else {
+			fmt.Fprintf(&b, "- %d booked after %d failed attempts\n", o.BookedAfter[failures], failures)
+		}
+	}
+	for _, attempts := range sortedKeys(o.GaveUpAfter) {
+		fmt.Fprintf(&b, "- %d gave up after %d attempts\n", o.GaveUpAfter[attempts], attempts)
+	}
+
+	if len(r.Errors) > 0 {
+		attempts := 0
+		for _, e := range r.Errors {
+			attempts = max(attempts, len(e.ByAttempt))
+		}
+
+		b.WriteString("\n## Errors\n\n| SQLSTATE | phase |")
+		for attempt := 1; attempt <= attempts; attempt++ {
+			fmt.Fprintf(&b, " attempt %d |", attempt)
+		}
+		b.WriteString(" total |\n|---|---|" + strings.Repeat("---:|", attempts+1) + "\n")
+
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "| %s | %s |", strings.TrimSpace(e.SQLState+" "+e.Name), e.Phase)
+			for attempt := 0; attempt < attempts; attempt++ {
+				n := 0
+				if attempt < len(e.ByAttempt) {
+					n = e.ByAttempt[attempt]
+				}
+				fmt.Fprintf(&b, " %d |", n)
+			}
+			fmt.Fprintf(&b, " %d |\n", e.Total)
+		}
+	}
+
+	if s := r.ServerStats; s != nil {
+		b.WriteString("\n## Server counters\n\n| Counter | Value |\n|---|---:|\n")
+		fmt.Fprintf(&b, "| deadlocks | %d |\n| xact_commit | %d |\n| xact_rollback | %d |\n| tup_updated | %d |\n| WAL bytes | %d |\n",
+			s.Deadlocks, s.XactCommit, s.XactRollback, s.TupUpdated, s.WALBytes)
+		fmt.Fprintf(&b, "| %s updates | %d |\n| %s HOT updates | %d (%.1f%%) |\n",
+			s.Table.Name, s.Table.Updates, s.Table.Name, s.Table.HOTUpdates, 100*s.HOTRatio())
+	}
+
+	if r.SeatMap != nil {
+		b.WriteString("\n## Seat map\n\n```\n")
+		if err := r.SeatMap.WriteText(&b); err != nil {
+			return err
+		}
+		b.WriteString("```\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes the pipes of a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,187 @@
+// Package report is the report of a booking run: its configuration, timing, latency percentiles, error taxonomy,
+// server counters and final seat assignment. A report is written as JSON for machines, as CSV rows for spreadsheets
+// and as a Markdown summary for PRs.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+	"github.com/soumya-codes/airline-reservation-poc/internal/histogram"
+	pgserverstats "github.com/soumya-codes/airline-reservation-poc/internal/postgres/serverstats"
+)
+
+// Config is the settings a run was executed with.
+type Config struct {
+	IsolationLevel   string `json:"isolation_level"`
+	LockStrategy     string `json:"lock_strategy"`
+	ClaimMode        string `json:"claim_mode"`
+	PoolSize         int    `json:"pool_size"`
+	PoolPolicy       string `json:"pool_policy"`
+	MaxRetries       int    `json:"max_retries"`
+	SavepointRetries int    `json:"savepoint_retries"`
+	QueryExecMode    string `json:"query_exec_mode"`
+	CabinLayout      string `json:"cabin_layout"`
+	// SessionSettings are the session level settings applied to every connection, e.g. "lock_timeout=1s ...".
+	SessionSettings string `json:"session_settings"`
+}
+
+// Latency is the latency percentiles of a run.
+type Latency struct {
+	// Booking is the time from the booking request to the successful booking, including all failed attempts.
+	Booking histogram.Summary `json:"booking"`
+	// Attempt is the time every attempt spent on its connection, successful or not.
+	Attempt histogram.Summary `json:"attempt"`
+	// AcquireWait is the time every attempt waited for a pool connection.
+	AcquireWait histogram.Summary `json:"acquire_wait"`
+	// Commit is the time the commit of every committed attempt took.
+	Commit histogram.Summary `json:"commit"`
+}
+
+// Outcomes are the attempts of a run and what became of its passengers.
+type Outcomes struct {
+	Passengers     int `json:"passengers"`
+	Bookings       int `json:"bookings"`
+	Attempts       int `json:"attempts"`
+	FailedAttempts int `json:"failed_attempts"`
+	// TransactionRetries is the number of attempts made beyond the first attempt of every passenger.
+	TransactionRetries int           `json:"transaction_retries"`
+	SavepointRetries   int           `json:"savepoint_retries"`
+	RoundTrips         int64         `json:"round_trips"`
+	MeanLockHold       time.Duration `json:"mean_lock_hold"`
+	MaxLockHold        time.Duration `json:"max_lock_hold"`
+	// BookedAfter counts the booked passengers by the number of failed attempts before their booking.
+	BookedAfter map[int]int `json:"booked_after"`
+	// GaveUpAfter counts the passengers that weren't booked by the number of attempts they made.
+	GaveUpAfter map[int]int `json:"gave_up_after"`
+}
+
+// Fairness is how closely the pool handed over its connections in the arrival order of the acquire requests.
+type Fairness struct {
+	Inversions       int     `json:"inversions"`
+	KendallTau       float64 `json:"kendall_tau"`
+	MeanDisplacement float64 `json:"mean_displacement"`
+	MaxDisplacement  int     `json:"max_displacement"`
+}
+
+// ErrorCount is the number of attempts that failed with a SQLSTATE in a phase of the booking.
+type ErrorCount struct {
+	SQLState string `json:"sqlstate"`
+	// Name is the condition name of the SQLSTATE, if it's one the bookings are expected to fail with.
+	Name  string `json:"name,omitempty"`
+	Phase string `json:"phase"`
+	// ByAttempt are the failures by attempt number, starting with the first attempt.
+	ByAttempt []int `json:"by_attempt"`
+	Total     int   `json:"total"`
+}
+
+// Run is the report of a run. Durations are in nanoseconds in JSON.
+type Run struct {
+	RunID     string    `json:"run_id"`
+	TripID    int32     `json:"trip_id"`
+	StartedAt time.Time `json:"started_at"`
+	Config    Config    `json:"config"`
+	// Elapsed is the time taken to book the seats of the trip.
+	Elapsed time.Duration `json:"elapsed"`
+	// Throughput is the number of bookings per second.
+	Throughput float64  `json:"throughput"`
+	Latency    Latency  `json:"latency"`
+	Outcomes   Outcomes `json:"outcomes"`
+	Fairness   Fairness `json:"fairness"`
+	// Errors are the failed attempts by cause, the most frequent first.
+	Errors []ErrorCount `json:"errors"`
+	// ServerStats is the change of the server statistics over the run, nil if they couldn't be read.
+	ServerStats *pgserverstats.Counters `json:"server_stats,omitempty"`
+	// SeatMap is the final seat assignment of the trip, nil if it couldn't be read.
+	SeatMap *cabin.SeatMap `json:"seat_map,omitempty"`
+}
+
+// Combination names the isolation level, lock strategy and pool size of the run, the runs of a combination are
+// compared with each other in the HTML report.
+func (r *Run) Combination() string {
+	return fmt.Sprintf("%s × %s × pool %d", r.Config.IsolationLevel, r.Config.LockStrategy, r.Config.PoolSize)
+}
+
+// FailureRate returns the share of the attempts that failed.
+func (r *Run) FailureRate() float64 {
+	if r.Outcomes.Attempts == 0 {
+		return 0
+	}
+
+	return float64(r.Outcomes.FailedAttempts) / float64(r.Outcomes.Attempts)
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func WriteJSON(w io.Writer, r *Run) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a report written by WriteJSON.
+func ReadJSON(r io.Reader) (*Run, error) {
+	var run Run
+	if err := json.NewDecoder(r).Decode(&run); err != nil {
+		return nil, fmt.Errorf("error decoding run report: %w", err)
+	}
+
+	return &run, nil
+}
+
+// Load reads the report from the JSON file with the name.
+func Load(name string) (*Run, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening run report: %w", err)
+	}
+	defer f.Close()
+
+	run, err := ReadJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return run, nil
+}
+
+// LoadAll reads the reports of the JSON files and of the report-*.json files of the directories with the names,
+// in the order the runs started.
+func LoadAll(names []string) ([]*Run, error) {
+	var files []string
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading run report: %w", err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(name, "report-*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing run reports of %s: %w", name, err)
+		}
+		files = append(files, matches...)
+	}
+
+	runs := make([]*Run, 0, len(files))
+	for _, file := range files {
+		run, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+
+	return runs, nil
+}
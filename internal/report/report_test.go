@@ -0,0 +1,186 @@
+package report
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soumya-codes/airline-reservation-poc/internal/booking/cabin"
+	"github.com/soumya-codes/airline-reservation-poc/internal/histogram"
+	pgserverstats "github.com/soumya-codes/airline-reservation-poc/internal/postgres/serverstats"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
+)
+
+func testRun(t *testing.T, runID string) *Run {
+	t.Helper()
+
+	layout := cabin.Layout{Name: "tiny", Rows: 1, Letters: "AB", AisleAfter: "A"}
+	seatMap, err := cabin.NewSeatMap(3, layout, []store.GetTripSeatsRow{{SeatID: "1A", PassengerID: 5, PassengerName: "Ada"}, {SeatID: "1B"}})
+	if err != nil {
+		t.Fatalf("NewSeatMap() error = %v", err)
+	}
+
+	return &Run{
+		RunID:      runID,
+		TripID:     3,
+		StartedAt:  time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC),
+		Config:     Config{IsolationLevel: "READ COMMITTED", LockStrategy: "GetSeatWithExclusiveLockSkipped", PoolSize: 10, CabinLayout: "tiny"},
+		Elapsed:    2 * time.Second,
+		Throughput: 0.5,
+		Latency:    Latency{Booking: histogram.Summary{Count: 1, P50: 3 * time.Millisecond, P99: 12500 * time.Microsecond}},
+		Outcomes:   Outcomes{Passengers: 2, Bookings: 1, Attempts: 4, FailedAttempts: 3, BookedAfter: map[int]int{0: 1}, GaveUpAfter: map[int]int{3: 1}},
+		Errors: []ErrorCount{
+			{SQLState: "40P01", Name: "deadlock_detected", Phase: "lock", ByAttempt: []int{1, 1, 0}, Total: 2},
+			{SQLState: "no_rows", Phase: "lock", ByAttempt: []int{0, 0, 1}, Total: 1},
+		},
+		ServerStats: &pgserverstats.Counters{Deadlocks: 2, Table: pgserverstats.TableCounters{Name: "reservation", Updates: 4, HOTUpdates: 1}},
+		SeatMap:     seatMap,
+	}
+}
+
+func TestJSON(t *testing.T) {
+	run := testRun(t, "r1")
+
+	var out strings.Builder
+	if err := WriteJSON(&out, run); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	read, err := ReadJSON(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+
+	if read.RunID != "r1" || read.Latency.Booking.P99 != run.Latency.Booking.P99 || read.Outcomes.GaveUpAfter[3] != 1 || read.ServerStats.Deadlocks != 2 {
+		t.Errorf("ReadJSON() = %+v, want the report written", read)
+	}
+
+	if seat, ok := read.SeatMap.Seat(1, 'A'); !ok || seat.PassengerName != "Ada" {
+		t.Errorf("Seat(1, 'A') of the report read back = %+v, %v, want the seat of Ada", seat, ok)
+	}
+
+	if got := read.FailureRate(); got != 0.75 {
+		t.Errorf("FailureRate() = %v, want 0.75", got)
+	}
+}
+
+func TestAppendCSV(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "runs.csv")
+	for _, runID := range []string{"r1", "r2"} {
+		if err := AppendCSV(name, testRun(t, runID)); err != nil {
+			t.Fatalf("AppendCSV() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("AppendCSV() wrote invalid CSV: %v", err)
+	}
+
+	if len(records) != 3 || records[0][0] != "run_id" || records[2][0] != "r2" {
+		t.Fatalf("AppendCSV() = %v, want a header and a row per run", records)
+	}
+
+	row := make(map[string]string, len(CSVHeader))
+	for i, column := range CSVHeader {
+		row[column] = records[1][i]
+	}
+
+	if row["booking_p99_ms"] != "12.500" || row["errors"] != "40P01 lock=2; no_rows lock=1" || row["hot_ratio"] != "0.2500" {
+		t.Errorf("CSVRecord() = %v, want durations in milliseconds and the errors by cause", row)
+	}
+}
+
+func TestAppendCSVRotatesOutdatedHeader(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "runs.csv")
+	if err := os.WriteFile(name, []byte("run_id,throughput\nr0,1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendCSV(name, testRun(t, "r1")); err != nil {
+		t.Fatalf("AppendCSV() error = %v", err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(records) != 2 || records[0][len(CSVHeader)-1] != CSVHeader[len(CSVHeader)-1] || records[1][0] != "r1" {
+		t.Fatalf("AppendCSV() = %v, %v, want a new file with the current header and the row of r1", records, err)
+	}
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "runs-*.csv"))
+	if err != nil || len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, %v, want the file with the outdated header", rotated, err)
+	}
+	if old, err := os.ReadFile(rotated[0]); err != nil || !strings.Contains(string(old), "r0,1.0") {
+		t.Errorf("rotated file = %q, %v, want the rows of the outdated header", old, err)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var out strings.Builder
+	if err := WriteMarkdown(&out, testRun(t, "r1")); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"# Run r1",
+		"| Lock strategy | GetSeatWithExclusiveLockSkipped |",
+		"| booking | 1 | 0s | 3ms | 0s | 12.5ms | 0s |",
+		"| 40P01 deadlock_detected | lock | 1 | 1 | 0 | 2 |",
+		"- 1 gave up after 3 attempts",
+		"| reservation HOT updates | 1 (25.0%) |",
+		"  A  x",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteMarkdown() =\n%s\nwant it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	r1, r2, r3 := testRun(t, "r1"), testRun(t, "r2"), testRun(t, "r3")
+	r2.Throughput = 1.5
+	r3.Config.IsolationLevel = "SERIALIZABLE"
+	r3.SeatMap.Seats[0].PassengerName = "<script>"
+
+	var out strings.Builder
+	if err := WriteHTML(&out, "Isolation levels", []*Run{r1, r2, r3}); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	page := out.String()
+
+	for _, want := range []string{
+		"3 runs of 2 isolation level × lock strategy × pool size combinations",
+		"READ COMMITTED × GetSeatWithExclusiveLockSkipped × pool 10",
+		// The mean throughput of r1 and r2, and the whisker over their range
+		"throughput: 1.00 bookings/s (0.50 to 1.50)",
+		"40P01 deadlock_detected (lock): 2.0 per run",
+		`<section class="run" id="run-r3">`,
+		"1A: &lt;script&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("WriteHTML() doesn't contain %q", want)
+		}
+	}
+
+	for _, external := range []string{"<script", "src=", "<link"} {
+		if strings.Contains(page, external) {
+			t.Errorf("WriteHTML() contains %q, want a self-contained page", external)
+		}
+	}
+}
@@ -4,6 +4,49 @@
 
 package store
 
+import (
+	"time"
+)
+
+type CabinLayout struct {
+	Identifier   int32    `db:"id" json:"id"`
+	Name         string   `db:"name" json:"name"`
+	RowCount     int32    `db:"row_count" json:"row_count"`
+	SeatLetters  string   `db:"seat_letters" json:"seat_letters"`
+	AisleAfter   string   `db:"aisle_after" json:"aisle_after"`
+	BlockedSeats []string `db:"blocked_seats" json:"blocked_seats"`
+}
+
+type ExperimentRun struct {
+	Identifier         int64     `db:"id" json:"id"`
+	RunID              string    `db:"run_id" json:"run_id"`
+	GitSha             string    `db:"git_sha" json:"git_sha"`
+	TripID             int32     `db:"trip_id" json:"trip_id"`
+	StartedAt          time.Time `db:"started_at" json:"started_at"`
+	ElapsedUs          int64     `db:"elapsed_us" json:"elapsed_us"`
+	IsolationLevel     string    `db:"isolation_level" json:"isolation_level"`
+	LockStrategy       string    `db:"lock_strategy" json:"lock_strategy"`
+	ClaimMode          string    `db:"claim_mode" json:"claim_mode"`
+	PoolSize           int32     `db:"pool_size" json:"pool_size"`
+	PoolPolicy         string    `db:"pool_policy" json:"pool_policy"`
+	MaxRetries         int32     `db:"max_retries" json:"max_retries"`
+	SavepointRetries   int32     `db:"savepoint_retries" json:"savepoint_retries"`
+	QueryExecMode      string    `db:"query_exec_mode" json:"query_exec_mode"`
+	CabinLayout        string    `db:"cabin_layout" json:"cabin_layout"`
+	Passengers         int32     `db:"passengers" json:"passengers"`
+	Bookings           int32     `db:"bookings" json:"bookings"`
+	Attempts           int32     `db:"attempts" json:"attempts"`
+	FailedAttempts     int32     `db:"failed_attempts" json:"failed_attempts"`
+	Throughput         float64   `db:"throughput" json:"throughput"`
+	BookingP50Us       int64     `db:"booking_p50_us" json:"booking_p50_us"`
+	BookingP90Us       int64     `db:"booking_p90_us" json:"booking_p90_us"`
+	BookingP99Us       int64     `db:"booking_p99_us" json:"booking_p99_us"`
+	BookingMaxUs       int64     `db:"booking_max_us" json:"booking_max_us"`
+	SessionSettings    string    `db:"session_settings" json:"session_settings"`
+	ServerSettings     []byte    `db:"server_settings" json:"server_settings"`
+	ServerSettingsHash string    `db:"server_settings_hash" json:"server_settings_hash"`
+}
+
 type Passenger struct {
 	Identifier int32  `db:"id" json:"id"`
 	Name       string `db:"name" json:"name"`
@@ -9,12 +9,12 @@ import (
 	"context"
 )
 
-const getPassengers = `-- name: GetPassengers :many
+const GetPassengers = `-- name: GetPassengers :many
 SELECT id, name FROM passenger ORDER BY id
 `
 
 func (q *Queries) GetPassengers(ctx context.Context) ([]Passenger, error) {
-	rows, err := q.db.Query(ctx, getPassengers)
+	rows, err := q.db.Query(ctx, GetPassengers)
 	if err != nil {
 		return nil, err
 	}
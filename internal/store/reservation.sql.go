@@ -9,7 +9,7 @@ import (
 	"context"
 )
 
-const bookSeat = `-- name: BookSeat :one
+const BookSeat = `-- name: BookSeat :one
 UPDATE reservation SET passenger_id = $1 WHERE id = $2 RETURNING 1
 `
 
@@ -19,13 +19,140 @@ type BookSeatParams struct {
 }
 
 func (q *Queries) BookSeat(ctx context.Context, arg BookSeatParams) (int32, error) {
-	row := q.db.QueryRow(ctx, bookSeat, arg.PassengerID, arg.Identifier)
+	row := q.db.QueryRow(ctx, BookSeat, arg.PassengerID, arg.Identifier)
 	var column_1 int32
 	err := row.Scan(&column_1)
 	return column_1, err
 }
 
-const getSeatWithExclusiveLock = `-- name: GetSeatWithExclusiveLock :one
+const BookSeatWithFunction = `-- name: BookSeatWithFunction :one
+SELECT id, seat_id FROM book_seat($1::int, $2::int, $3::text)
+`
+
+type BookSeatWithFunctionParams struct {
+	TripID      int32  `db:"trip_id" json:"trip_id"`
+	PassengerID int32  `db:"passenger_id" json:"passenger_id"`
+	LockMode    string `db:"lock_mode" json:"lock_mode"`
+}
+
+type BookSeatWithFunctionRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) BookSeatWithFunction(ctx context.Context, arg BookSeatWithFunctionParams) (BookSeatWithFunctionRow, error) {
+	row := q.db.QueryRow(ctx, BookSeatWithFunction, arg.TripID, arg.PassengerID, arg.LockMode)
+	var i BookSeatWithFunctionRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const ClaimSeatWithExclusiveLock = `-- name: ClaimSeatWithExclusiveLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE) RETURNING id, seat_id
+`
+
+type ClaimSeatWithExclusiveLockParams struct {
+	TripID      int32 `db:"trip_id" json:"trip_id"`
+	PassengerID int32 `db:"passenger_id" json:"passenger_id"`
+}
+
+type ClaimSeatWithExclusiveLockRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) ClaimSeatWithExclusiveLock(ctx context.Context, arg ClaimSeatWithExclusiveLockParams) (ClaimSeatWithExclusiveLockRow, error) {
+	row := q.db.QueryRow(ctx, ClaimSeatWithExclusiveLock, arg.TripID, arg.PassengerID)
+	var i ClaimSeatWithExclusiveLockRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const ClaimSeatWithExclusiveLockSkipped = `-- name: ClaimSeatWithExclusiveLockSkipped :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING id, seat_id
+`
+
+type ClaimSeatWithExclusiveLockSkippedParams struct {
+	TripID      int32 `db:"trip_id" json:"trip_id"`
+	PassengerID int32 `db:"passenger_id" json:"passenger_id"`
+}
+
+type ClaimSeatWithExclusiveLockSkippedRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) ClaimSeatWithExclusiveLockSkipped(ctx context.Context, arg ClaimSeatWithExclusiveLockSkippedParams) (ClaimSeatWithExclusiveLockSkippedRow, error) {
+	row := q.db.QueryRow(ctx, ClaimSeatWithExclusiveLockSkipped, arg.TripID, arg.PassengerID)
+	var i ClaimSeatWithExclusiveLockSkippedRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const ClaimSeatWithNoLock = `-- name: ClaimSeatWithNoLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1) RETURNING id, seat_id
+`
+
+type ClaimSeatWithNoLockParams struct {
+	TripID      int32 `db:"trip_id" json:"trip_id"`
+	PassengerID int32 `db:"passenger_id" json:"passenger_id"`
+}
+
+type ClaimSeatWithNoLockRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) ClaimSeatWithNoLock(ctx context.Context, arg ClaimSeatWithNoLockParams) (ClaimSeatWithNoLockRow, error) {
+	row := q.db.QueryRow(ctx, ClaimSeatWithNoLock, arg.TripID, arg.PassengerID)
+	var i ClaimSeatWithNoLockRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const ClaimSeatWithSharedLock = `-- name: ClaimSeatWithSharedLock :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE) RETURNING id, seat_id
+`
+
+type ClaimSeatWithSharedLockParams struct {
+	TripID      int32 `db:"trip_id" json:"trip_id"`
+	PassengerID int32 `db:"passenger_id" json:"passenger_id"`
+}
+
+type ClaimSeatWithSharedLockRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) ClaimSeatWithSharedLock(ctx context.Context, arg ClaimSeatWithSharedLockParams) (ClaimSeatWithSharedLockRow, error) {
+	row := q.db.QueryRow(ctx, ClaimSeatWithSharedLock, arg.TripID, arg.PassengerID)
+	var i ClaimSeatWithSharedLockRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const ClaimSeatWithSharedLockSkipped = `-- name: ClaimSeatWithSharedLockSkipped :one
+UPDATE reservation SET passenger_id = $2 WHERE id = (SELECT id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE SKIP LOCKED) RETURNING id, seat_id
+`
+
+type ClaimSeatWithSharedLockSkippedParams struct {
+	TripID      int32 `db:"trip_id" json:"trip_id"`
+	PassengerID int32 `db:"passenger_id" json:"passenger_id"`
+}
+
+type ClaimSeatWithSharedLockSkippedRow struct {
+	Identifier int32  `db:"id" json:"id"`
+	SeatID     string `db:"seat_id" json:"seat_id"`
+}
+
+func (q *Queries) ClaimSeatWithSharedLockSkipped(ctx context.Context, arg ClaimSeatWithSharedLockSkippedParams) (ClaimSeatWithSharedLockSkippedRow, error) {
+	row := q.db.QueryRow(ctx, ClaimSeatWithSharedLockSkipped, arg.TripID, arg.PassengerID)
+	var i ClaimSeatWithSharedLockSkippedRow
+	err := row.Scan(&i.Identifier, &i.SeatID)
+	return i, err
+}
+
+const GetSeatWithExclusiveLock = `-- name: GetSeatWithExclusiveLock :one
 SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE
 `
 
@@ -35,13 +162,13 @@ type GetSeatWithExclusiveLockRow struct {
 }
 
 func (q *Queries) GetSeatWithExclusiveLock(ctx context.Context, tripID int32) (GetSeatWithExclusiveLockRow, error) {
-	row := q.db.QueryRow(ctx, getSeatWithExclusiveLock, tripID)
+	row := q.db.QueryRow(ctx, GetSeatWithExclusiveLock, tripID)
 	var i GetSeatWithExclusiveLockRow
 	err := row.Scan(&i.Identifier, &i.SeatID)
 	return i, err
 }
 
-const getSeatWithExclusiveLockSkipped = `-- name: GetSeatWithExclusiveLockSkipped :one
+const GetSeatWithExclusiveLockSkipped = `-- name: GetSeatWithExclusiveLockSkipped :one
 SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED
 `
 
@@ -51,13 +178,13 @@ type GetSeatWithExclusiveLockSkippedRow struct {
 }
 
 func (q *Queries) GetSeatWithExclusiveLockSkipped(ctx context.Context, tripID int32) (GetSeatWithExclusiveLockSkippedRow, error) {
-	row := q.db.QueryRow(ctx, getSeatWithExclusiveLockSkipped, tripID)
+	row := q.db.QueryRow(ctx, GetSeatWithExclusiveLockSkipped, tripID)
 	var i GetSeatWithExclusiveLockSkippedRow
 	err := row.Scan(&i.Identifier, &i.SeatID)
 	return i, err
 }
 
-const getSeatWithNoLock = `-- name: GetSeatWithNoLock :one
+const GetSeatWithNoLock = `-- name: GetSeatWithNoLock :one
 SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1
 `
 
@@ -67,13 +194,13 @@ type GetSeatWithNoLockRow struct {
 }
 
 func (q *Queries) GetSeatWithNoLock(ctx context.Context, tripID int32) (GetSeatWithNoLockRow, error) {
-	row := q.db.QueryRow(ctx, getSeatWithNoLock, tripID)
+	row := q.db.QueryRow(ctx, GetSeatWithNoLock, tripID)
 	var i GetSeatWithNoLockRow
 	err := row.Scan(&i.Identifier, &i.SeatID)
 	return i, err
 }
 
-const getSeatWithSharedLock = `-- name: GetSeatWithSharedLock :one
+const GetSeatWithSharedLock = `-- name: GetSeatWithSharedLock :one
 SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE
 `
 
@@ -83,13 +210,13 @@ type GetSeatWithSharedLockRow struct {
 }
 
 func (q *Queries) GetSeatWithSharedLock(ctx context.Context, tripID int32) (GetSeatWithSharedLockRow, error) {
-	row := q.db.QueryRow(ctx, getSeatWithSharedLock, tripID)
+	row := q.db.QueryRow(ctx, GetSeatWithSharedLock, tripID)
 	var i GetSeatWithSharedLockRow
 	err := row.Scan(&i.Identifier, &i.SeatID)
 	return i, err
 }
 
-const getSeatWithSharedLockSkipped = `-- name: GetSeatWithSharedLockSkipped :one
+const GetSeatWithSharedLockSkipped = `-- name: GetSeatWithSharedLockSkipped :one
 SELECT id, seat_id FROM reservation WHERE trip_id = $1 AND passenger_id IS NULL ORDER BY id LIMIT 1 FOR SHARE SKIP LOCKED
 `
 
@@ -99,23 +226,26 @@ type GetSeatWithSharedLockSkippedRow struct {
 }
 
 func (q *Queries) GetSeatWithSharedLockSkipped(ctx context.Context, tripID int32) (GetSeatWithSharedLockSkippedRow, error) {
-	row := q.db.QueryRow(ctx, getSeatWithSharedLockSkipped, tripID)
+	row := q.db.QueryRow(ctx, GetSeatWithSharedLockSkipped, tripID)
 	var i GetSeatWithSharedLockSkippedRow
 	err := row.Scan(&i.Identifier, &i.SeatID)
 	return i, err
 }
 
-const getTripSeats = `-- name: GetTripSeats :many
-SELECT seat_id, passenger_id FROM reservation WHERE trip_id = $1 ORDER BY seat_id
+const GetTripSeats = `-- name: GetTripSeats :many
+SELECT reservation.seat_id, COALESCE(reservation.passenger_id, 0)::int AS passenger_id, COALESCE(passenger.name, '')::text AS passenger_name
+FROM reservation LEFT JOIN passenger ON passenger.id = reservation.passenger_id
+WHERE reservation.trip_id = $1 ORDER BY reservation.id
 `
 
 type GetTripSeatsRow struct {
-	SeatID      string `db:"seat_id" json:"seat_id"`
-	PassengerID int32  `db:"passenger_id" json:"passenger_id"`
+	SeatID        string `db:"seat_id" json:"seat_id"`
+	PassengerID   int32  `db:"passenger_id" json:"passenger_id"`
+	PassengerName string `db:"passenger_name" json:"passenger_name"`
 }
 
 func (q *Queries) GetTripSeats(ctx context.Context, tripID int32) ([]GetTripSeatsRow, error) {
-	rows, err := q.db.Query(ctx, getTripSeats, tripID)
+	rows, err := q.db.Query(ctx, GetTripSeats, tripID)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +253,7 @@ func (q *Queries) GetTripSeats(ctx context.Context, tripID int32) ([]GetTripSeat
 	var items []GetTripSeatsRow
 	for rows.Next() {
 		var i GetTripSeatsRow
-		if err := rows.Scan(&i.SeatID, &i.PassengerID); err != nil {
+		if err := rows.Scan(&i.SeatID, &i.PassengerID, &i.PassengerName); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -9,23 +9,54 @@ import (
 	"context"
 )
 
-const getNextAvailableTrip = `-- name: GetNextAvailableTrip :one
+const GetNextAvailableTrip = `-- name: GetNextAvailableTrip :one
 SELECT id FROM trip WHERE booked = FALSE ORDER BY schedule LIMIT 1 FOR UPDATE SKIP LOCKED
 `
 
 func (q *Queries) GetNextAvailableTrip(ctx context.Context) (int32, error) {
-	row := q.db.QueryRow(ctx, getNextAvailableTrip)
+	row := q.db.QueryRow(ctx, GetNextAvailableTrip)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
-const markTripForBooking = `-- name: MarkTripForBooking :one
+const GetNextAvailableTripWithCabinLayout = `-- name: GetNextAvailableTripWithCabinLayout :one
+SELECT trip.id FROM trip JOIN cabin_layout ON cabin_layout.id = trip.cabin_layout_id
+WHERE trip.booked = FALSE AND cabin_layout.name = $1 ORDER BY trip.schedule LIMIT 1 FOR UPDATE OF trip SKIP LOCKED
+`
+
+func (q *Queries) GetNextAvailableTripWithCabinLayout(ctx context.Context, name string) (int32, error) {
+	row := q.db.QueryRow(ctx, GetNextAvailableTripWithCabinLayout, name)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const GetTripCabinLayout = `-- name: GetTripCabinLayout :one
+SELECT cabin_layout.id, cabin_layout.name, cabin_layout.row_count, cabin_layout.seat_letters, cabin_layout.aisle_after, cabin_layout.blocked_seats
+FROM trip JOIN cabin_layout ON cabin_layout.id = trip.cabin_layout_id WHERE trip.id = $1
+`
+
+func (q *Queries) GetTripCabinLayout(ctx context.Context, id int32) (CabinLayout, error) {
+	row := q.db.QueryRow(ctx, GetTripCabinLayout, id)
+	var i CabinLayout
+	err := row.Scan(
+		&i.Identifier,
+		&i.Name,
+		&i.RowCount,
+		&i.SeatLetters,
+		&i.AisleAfter,
+		&i.BlockedSeats,
+	)
+	return i, err
+}
+
+const MarkTripForBooking = `-- name: MarkTripForBooking :one
 UPDATE trip SET booked = TRUE WHERE id = $1 RETURNING 1
 `
 
 func (q *Queries) MarkTripForBooking(ctx context.Context, id int32) (int32, error) {
-	row := q.db.QueryRow(ctx, markTripForBooking, id)
+	row := q.db.QueryRow(ctx, MarkTripForBooking, id)
 	var column_1 int32
 	err := row.Scan(&column_1)
 	return column_1, err
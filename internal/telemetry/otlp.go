@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// scopeName is the instrumentation scope the spans are reported under.
+const scopeName = "github.com/soumya-codes/airline-reservation-poc"
+
+// The types below mirror the JSON encoding of an OTLP ExportTraceServiceRequest, so the file written by WriteOTLP
+// can be posted as is to the /v1/traces endpoint of a collector.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue, OTLP JSON encodes 64 bit integers as strings.
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+// WriteOTLP writes the spans as an OTLP JSON trace export request of the service.
+func WriteOTLP(w io.Writer, service string, spans []SpanData) error {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: scopeName}, Spans: make([]otlpSpan, 0, len(spans))}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: fmt.Sprint(s.Start.UnixNano()),
+			EndTimeUnixNano:   fmt.Sprint(s.End.UnixNano()),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status.Code, Message: s.Status.Message},
+		}
+
+		if s.Parent.IsValid() {
+			span.ParentSpanID = s.Parent.String()
+		}
+
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes([]Attribute{String("service.name", service)})},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(request)
+}
+
+func otlpAttributes(attrs []Attribute) []otlpAttribute {
+	out := make([]otlpAttribute, 0, len(attrs))
+	for _, a := range attrs {
+		var value otlpValue
+		switch v := a.Value.(type) {
+		case int64:
+			s := fmt.Sprint(v)
+			value.IntValue = &s
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+
+		out = append(out, otlpAttribute{Key: a.Key, Value: value})
+	}
+
+	return out
+}
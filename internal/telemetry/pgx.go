@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgstatements "github.com/soumya-codes/airline-reservation-poc/internal/postgres/statements"
+)
+
+// QueryTracer is a pgx QueryTracer and BatchTracer that records a Client span for every query sent on a connection,
+// as a child of the span the query's context carries.
+type QueryTracer struct {
+	tracer *Tracer
+}
+
+func NewQueryTracer(tracer *Tracer) *QueryTracer {
+	return &QueryTracer{tracer: tracer}
+}
+
+type batchKey struct{}
+
+// batch is the span of a batch and the end of its last query, the queries of a batch are sent together,
+// so the span of each of them starts at the end of the previous one.
+type batch struct {
+	span    *Span
+	lastEnd time.Time
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	s := t.tracer.StartAt(ctx, pgstatements.QueryName(data.SQL), Client, time.Now(), queryAttributes(conn, data.SQL)...)
+	return ContextWithSpan(ctx, s)
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endQuerySpan(SpanFromContext(ctx), data.Err, time.Now())
+}
+
+func (t *QueryTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	start := time.Now()
+	s := t.tracer.StartAt(ctx, "batch", Client, start, queryAttributes(conn, "")...)
+	if data.Batch != nil {
+		s.SetAttributes(Int("db.batch.size", int64(data.Batch.Len())))
+	}
+
+	return context.WithValue(ContextWithSpan(ctx, s), batchKey{}, &batch{span: s, lastEnd: start})
+}
+
+func (t *QueryTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	b, ok := ctx.Value(batchKey{}).(*batch)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	s := t.tracer.StartAt(ctx, pgstatements.QueryName(data.SQL), Client, b.lastEnd, queryAttributes(conn, data.SQL)...)
+	endQuerySpan(s, data.Err, end)
+	b.lastEnd = end
+}
+
+func (t *QueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	b, ok := ctx.Value(batchKey{}).(*batch)
+	if !ok {
+		return
+	}
+
+	endQuerySpan(b.span, data.Err, time.Now())
+}
+
+// queryAttributes are the attributes of the span of a query sent on conn, following the OpenTelemetry database conventions.
+func queryAttributes(conn *pgx.Conn, sql string) []Attribute {
+	attrs := []Attribute{
+		String("db.system", "postgresql"),
+		Int("db.postgresql.pid", int64(conn.PgConn().PID())),
+	}
+
+	if sql != "" {
+		attrs = append(attrs, String("db.statement", sql))
+	}
+
+	return attrs
+}
+
+// endQuerySpan ends the span of a query, recording the query's error and SQLSTATE if it failed.
+func endQuerySpan(s *Span, err error, end time.Time) {
+	if err != nil {
+		s.RecordError(err)
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			s.SetAttributes(String("db.sqlstate", pgErr.Code))
+		}
+	}
+
+	s.EndAt(end)
+}
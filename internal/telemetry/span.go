@@ -0,0 +1,270 @@
+// Package telemetry records OpenTelemetry style spans of the booking flows in memory, and writes them as OTLP JSON,
+// so the traces of a run can be inspected offline or replayed into a collector without a live backend.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, the spans of a run share the trace ID of its root span.
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within its trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the ID is set, the zero ID is the parent of a root span.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanKind is the OTLP kind of a span.
+type SpanKind int
+
+const (
+	// Internal is a span of an operation within the process.
+	Internal SpanKind = 1
+	// Client is a span of a request to a remote service, e.g. a query sent to Postgres.
+	Client SpanKind = 3
+)
+
+// StatusCode is the OTLP status of a span.
+type StatusCode int
+
+const (
+	Unset StatusCode = 0
+	OK    StatusCode = 1
+	Error StatusCode = 2
+)
+
+// Status is the outcome of the operation of a span.
+type Status struct {
+	Code    StatusCode
+	Message string
+}
+
+// Attribute is a key-value pair describing a span, the value is either a string or an int64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is an ended span.
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	TraceID    TraceID
+	SpanID     SpanID
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Status     Status
+}
+
+// Attribute returns the value of the attribute with the key, the last one wins if it was set more than once.
+func (s SpanData) Attribute(key string) (any, bool) {
+	for i := len(s.Attributes) - 1; i >= 0; i-- {
+		if s.Attributes[i].Key == key {
+			return s.Attributes[i].Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Span is a span that is being recorded, it is recorded by its Tracer when it is ended.
+// The methods of a nil Span are no-ops, so the code being traced doesn't have to check whether tracing is enabled.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// SetAttributes adds the attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetStatus sets the outcome of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Status = Status{Code: code, Message: message}
+}
+
+// RecordError sets the status of the span to Error with the error's message, a nil error leaves the status unchanged.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.SetStatus(Error, err.Error())
+}
+
+// End ends the span now, only the first call has an effect.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt ends the span at end, only the first call has an effect.
+func (s *Span) EndAt(end time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = end
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.record(data)
+}
+
+// Tracer starts spans and keeps the first capacity ended spans, counting the ones it drops.
+// It is safe for concurrent use, and a nil Tracer starts nil spans.
+type Tracer struct {
+	mu       sync.Mutex
+	spans    []SpanData
+	capacity int
+	dropped  int
+}
+
+func NewTracer(capacity int) *Tracer {
+	return &Tracer{spans: make([]SpanData, 0, min(capacity, 1024)), capacity: capacity}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context carrying the span, spans started with it become its children.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext returns the span the context carries, nil if it carries none.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Start starts an Internal span now as a child of the span the context carries, recorded by the same Tracer,
+// and returns a context carrying the new span. If the context carries no span, it returns the context and a nil span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	return parent.tracer.Start(ctx, name, attrs...)
+}
+
+// Start starts an Internal span now, as a child of the span the context carries, and returns a context carrying the new span.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := t.StartAt(ctx, name, Internal, time.Now(), attrs...)
+	return ContextWithSpan(ctx, s), s
+}
+
+// StartAt starts a span of the kind at start, as a child of the span the context carries.
+func (t *Tracer) StartAt(ctx context.Context, name string, kind SpanKind, start time.Time, attrs ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+
+	data := SpanData{
+		Name:       name,
+		Kind:       kind,
+		SpanID:     newSpanID(),
+		Start:      start,
+		Attributes: attrs,
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		data.TraceID = parent.data.TraceID
+		data.Parent = parent.data.SpanID
+	} else {
+		data.TraceID = newTraceID()
+	}
+
+	return &Span{tracer: t, data: data}
+}
+
+func (t *Tracer) record(data SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.spans) >= t.capacity {
+		t.dropped++
+		return
+	}
+
+	t.spans = append(t.spans, data)
+}
+
+// Spans returns a copy of the spans ended so far, in the order they ended.
+func (t *Tracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]SpanData(nil), t.spans...)
+}
+
+// Dropped returns the number of ended spans dropped because the tracer was full.
+func (t *Tracer) Dropped() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.dropped
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
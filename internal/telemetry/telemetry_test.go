@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	if ctx, span := Start(context.Background(), "bookSeatTask"); span != nil || SpanFromContext(ctx) != nil {
+		t.Fatalf("Start() without a parent span = %v, want a nil span", span)
+	}
+
+	tracer := NewTracer(2)
+	ctx, root := tracer.Start(context.Background(), "BookSeats", Int("trip.id", 7))
+	_, child := Start(ctx, "bookSeatTask")
+	child.SetAttributes(String("seat.id", "1A"))
+	child.RecordError(errors.New("deadlock detected"))
+	child.End()
+	root.End()
+	root.End()
+
+	var nilSpan *Span
+	nilSpan.SetAttributes(String("seat.id", "1B"))
+	nilSpan.End()
+
+	_, dropped := tracer.Start(context.Background(), "BookSeats")
+	dropped.End()
+
+	spans := tracer.Spans()
+	if len(spans) != 2 || tracer.Dropped() != 1 {
+		t.Fatalf("Spans() = %d spans, %d dropped, want 2 spans, 1 dropped", len(spans), tracer.Dropped())
+	}
+
+	task, run := spans[0], spans[1]
+	if task.TraceID != run.TraceID || task.Parent != run.SpanID || run.Parent.IsValid() {
+		t.Errorf("bookSeatTask span %+v isn't a child of the BookSeats span %+v", task, run)
+	}
+
+	if seat, _ := task.Attribute("seat.id"); seat != "1A" {
+		t.Errorf("seat.id = %v, want 1A", seat)
+	}
+
+	if task.Status != (Status{Code: Error, Message: "deadlock detected"}) {
+		t.Errorf("Status = %+v, want the recorded error", task.Status)
+	}
+}
+
+func TestWriteOTLP(t *testing.T) {
+	tracer := NewTracer(10)
+	ctx, root := tracer.Start(context.Background(), "BookSeats", Int("trip.id", 7), String("db.isolation_level", "READ COMMITTED"))
+	_, child := Start(ctx, "bookSeatTask")
+	child.End()
+	root.End()
+
+	var out strings.Builder
+	if err := WriteOTLP(&out, "airline-reservation-poc", tracer.Spans()); err != nil {
+		t.Fatalf("WriteOTLP() error = %v", err)
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Attributes   []struct {
+						Key   string            `json:"key"`
+						Value map[string]string `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &request); err != nil {
+		t.Fatalf("WriteOTLP() wrote invalid JSON: %v", err)
+	}
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("WriteOTLP() wrote %d spans, want 2", len(spans))
+	}
+
+	task, run := spans[0], spans[1]
+	if len(run.TraceID) != 32 || len(run.SpanID) != 16 || run.ParentSpanID != "" || task.ParentSpanID != run.SpanID {
+		t.Errorf("WriteOTLP() IDs = %+v, %+v, want hex IDs with bookSeatTask a child of BookSeats", run, task)
+	}
+
+	if got := run.Attributes[0]; got.Key != "trip.id" || got.Value["intValue"] != "7" {
+		t.Errorf("WriteOTLP() attribute = %+v, want trip.id as an intValue of 7", got)
+	}
+
+	if got := run.Attributes[1]; got.Value["stringValue"] != "READ COMMITTED" {
+		t.Errorf("WriteOTLP() attribute = %+v, want db.isolation_level as a stringValue", got)
+	}
+}
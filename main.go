@@ -2,20 +2,55 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"runtime"
 	"time"
 
 	"github.com/soumya-codes/airline-reservation-poc/config"
 	"github.com/soumya-codes/airline-reservation-poc/internal/booking"
 	"github.com/soumya-codes/airline-reservation-poc/internal/booking/seat"
+	"github.com/soumya-codes/airline-reservation-poc/internal/experiment"
+	pgconn "github.com/soumya-codes/airline-reservation-poc/internal/postgres/connection"
 	pgtx "github.com/soumya-codes/airline-reservation-poc/internal/postgres/transaction"
+	"github.com/soumya-codes/airline-reservation-poc/internal/report"
+	"github.com/soumya-codes/airline-reservation-poc/internal/store"
 )
 
+// Usage:
+//
+//	go run .                      books the seats of the next available trip
+//	go run . explain [-dir plans] explains the queries of every lock strategy against the next available trip
+//	go run . report [-o report.html] [-title title] <report.json|dir>...
+//	                              renders the run reports, and the report-*.json files of the directories, into an offline HTML file
+//	go run . compare [-runs 5] [-throughput 0.1] [-p99 0.1] [-failure-rate 1] <baseline> <candidate>
+//	                              compares two recorded runs, or the recent runs of two configurations, see experiment.Selector
 func main() {
 	// Set the maximum number of CPUs that can be executing simultaneously.
 	runtime.GOMAXPROCS(8)
 
+	command := "book"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "book":
+		book()
+	case "explain":
+		explain(os.Args[2:])
+	case "report":
+		htmlReport(os.Args[2:])
+	case "compare":
+		compare(os.Args[2:])
+	default:
+		log.Fatalf("Unknown command %q, expected one of: book, explain, report, compare", command)
+	}
+}
+
+func book() {
 	//cfg := config.DefaultConfig()
 
 	cfg := config.NewConfig(
@@ -33,3 +68,117 @@ func main() {
 		log.Fatalf("Error running booking process: %v", err)
 	}
 }
+
+func explain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	dir := flags.String("dir", "plans", "directory to write the query plans to")
+	_ = flags.Parse(args)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancelFunc()
+
+	err := booking.ExplainStrategies(ctx, config.DefaultConfig(), *dir)
+	if err != nil {
+		log.Fatalf("Error explaining the lock strategies: %v", err)
+	}
+}
+
+func htmlReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	out := flags.String("o", "report.html", "file to write the HTML report to")
+	title := flags.String("title", "Airline reservation experiment", "title of the HTML report")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("No run reports given, expected report-<run id>.json files or the directories they were written to")
+	}
+
+	runs, err := report.LoadAll(flags.Args())
+	if err != nil {
+		log.Fatalf("Error reading the run reports: %v", err)
+	}
+	if len(runs) == 0 {
+		log.Fatalf("No run reports found in %v", flags.Args())
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Error creating the HTML report: %v", err)
+	}
+
+	if err := report.WriteHTML(f, *title, runs); err != nil {
+		_ = f.Close()
+		log.Fatalf("Error writing the HTML report: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		log.Fatalf("Error closing the HTML report: %v", err)
+	}
+
+	log.Printf("HTML report of %d runs written to %s", len(runs), *out)
+}
+
+// compare prints the throughput, p99 latency and failure rate of the baseline and the candidate, and exits with
+// status 1 if the candidate regressed, so it can gate a change.
+func compare(args []string) {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	runs := flags.Int("runs", 5, "number of the most recent runs of a configuration to compare")
+	throughput := flags.Float64("throughput", experiment.DefaultThresholds.Throughput, "relative throughput drop reported as a regression")
+	p99 := flags.Float64("p99", experiment.DefaultThresholds.P99, "relative p99 latency increase reported as a regression")
+	failureRate := flags.Float64("failure-rate", experiment.DefaultThresholds.FailureRate, "failure rate increase in percentage points reported as a regression")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Fatal("Expected a baseline and a candidate, each a run id or <isolation level>/<lock strategy>/<pool size>[,<key>=<value>...][@<git sha>]")
+	}
+
+	thresholds := experiment.Thresholds{Throughput: *throughput, P99: *p99, FailureRate: *failureRate}
+	metrics, err := compareRuns(flags.Arg(0), flags.Arg(1), int32(*runs), thresholds)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if n := experiment.Regressions(metrics); n > 0 {
+		log.Printf("The candidate regressed on %d of %d metrics", n, len(metrics))
+		os.Exit(1)
+	}
+}
+
+// compareRuns selects the runs of the baseline and the candidate, and writes their comparison to stdout.
+func compareRuns(baseline, candidate string, maxRuns int32, thresholds experiment.Thresholds) ([]experiment.Metric, error) {
+	selectors := make([]experiment.Selector, 2)
+	for i, arg := range []string{baseline, candidate} {
+		selector, err := experiment.ParseSelector(arg)
+		if err != nil {
+			return nil, err
+		}
+		selectors[i] = selector
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancelFunc()
+
+	conn, err := pgconn.NewConnection(config.DefaultConfig().PostgresConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	defer func() {
+		_ = pgconn.Close(conn)
+	}()
+
+	q := store.New(conn)
+	summaries := make([]experiment.Summary, 2)
+	for i, selector := range selectors {
+		summaries[i], err = experiment.Select(ctx, q, selector, maxRuns)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting the runs to compare: %w", err)
+		}
+	}
+
+	metrics := experiment.Compare(summaries[0], summaries[1], thresholds)
+	if err := experiment.WriteComparison(os.Stdout, summaries[0], summaries[1], metrics); err != nil {
+		return nil, fmt.Errorf("error writing the comparison: %w", err)
+	}
+
+	return metrics, nil
+}